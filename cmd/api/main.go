@@ -16,12 +16,16 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-redis/redis/v8"
 
+	"social-api/internal/account"
 	"social-api/internal/auth"
 	"social-api/internal/cache"
 	"social-api/internal/config"
 	"social-api/internal/db"
+	"social-api/internal/feed"
 	"social-api/internal/handler"
+	"social-api/internal/mailer"
 	appMiddleware "social-api/internal/middleware"
+	"social-api/internal/model"
 
 	"social-api/internal/store/postgres"
 )
@@ -72,17 +76,154 @@ func main() {
 		logger.Println("Redis cache disabled")
 	}
 
+	// Configure password hashing; older hashes are upgraded as users log in
+	err = store.SetPasswordParams(store.PasswordParams{
+		Algorithm:         cfg.Password.Algorithm,
+		BcryptCost:        cfg.Password.BcryptCost,
+		Argon2Memory:      uint32(cfg.Password.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.Password.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.Password.Argon2Parallelism),
+	})
+	if err != nil {
+		logger.Fatalf("Invalid password hashing configuration: %v", err)
+	}
+	logger.Printf("Hashing new passwords with %s", cfg.Password.Algorithm)
+
+	// Configure which user fields each viewer can see
+	projection, err := model.NewUserProjection(
+		cfg.Projection.HiddenFromSelf,
+		cfg.Projection.HiddenFromAdmins,
+		cfg.Projection.HiddenFromOthers,
+	)
+	if err != nil {
+		logger.Fatalf("Invalid user field configuration: %v", err)
+	}
+
 	// Initialize stores
 	userStore := postgres.NewUserStore(database)
 	postStore := postgres.NewPostStore(database)
+	refreshTokenStore := postgres.NewRefreshTokenStore(database)
+	tokenRevocationStore := postgres.NewTokenRevocationStore(database)
+	apiKeyStore := postgres.NewAPIKeyStore(database)
+	passwordResetStore := postgres.NewPasswordResetStore(database)
+	auditStore := postgres.NewAuditStore(database)
+	mfaStore := postgres.NewMFAStore(database)
+	sessionStore := postgres.NewSessionStore(database)
+	userIdentityStore := postgres.NewUserIdentityStore(database)
+	oauthStore := postgres.NewOAuthStore(database)
+	commentStore := postgres.NewCommentStore(database)
+	reactionStore := postgres.NewReactionStore(database)
+	followStore := postgres.NewFollowStore(database)
+
+	// Load token signing keys
+	keys := auth.NewHMACKeySet(cfg.Auth.TokenSecret)
+	if cfg.Auth.SigningKeyFile != "" {
+		keys, err = auth.LoadKeySet(cfg.Auth.SigningKeyFile, cfg.Auth.VerificationKeyFiles)
+		if err != nil {
+			logger.Fatalf("Loading signing keys failed: %v", err)
+		}
+		logger.Printf("Signing tokens with %s key %s", keys.SigningKey().Method.Alg(), keys.SigningKey().ID)
+	}
 
 	// Initialize authenticator
 	authenticator := auth.NewJWTAuthenticator(
-		cfg.Auth.TokenSecret,
+		keys,
 		cfg.Auth.TokenIssuer,
 		cfg.Auth.TokenAudience,
 	)
 
+	// Initialize access token revocation list
+	revocations := auth.NewRevocationList(tokenRevocationStore, sessionStore, cacheService, cfg.Auth.TokenExpiry)
+
+	// Authentication schemes, tried in order
+	authSchemes := []auth.Scheme{
+		auth.NewBearerScheme(authenticator, revocations),
+		auth.NewAPIKeyScheme(auth.APIKeyHeader, auth.NewAPIKeyAuthenticator(apiKeyStore)),
+		auth.NewSessionScheme(cfg.Session.CookieName, auth.NewSessionAuthenticator(sessionStore)),
+	}
+
+	// Track session last-seen times, writing them in batches
+	sessionTracker := auth.NewSessionTracker(sessionStore, cfg.Session.LastSeenFlushInterval, logger)
+	trackerCtx, stopTracker := context.WithCancel(context.Background())
+	trackerDone := make(chan struct{})
+	go func() {
+		sessionTracker.Run(trackerCtx)
+		close(trackerDone)
+	}()
+
+	// Initialize signer for out-of-band tokens such as email verification links
+	signingSecret := cfg.Auth.SigningSecret
+	if signingSecret == "" && cfg.Auth.TokenSecret != config.DefaultTokenSecret {
+		// Deployments from before AUTH_SIGNING_SECRET keep working for now
+		logger.Println("WARNING: AUTH_SIGNING_SECRET is not set; falling back to AUTH_TOKEN_SECRET. This fallback will be removed in the next release.")
+		signingSecret = cfg.Auth.TokenSecret
+	}
+	if signingSecret == "" || signingSecret == config.DefaultTokenSecret {
+		logger.Fatal("AUTH_SIGNING_SECRET must be set to a private random value")
+	}
+	signer := auth.NewTokenSigner(signingSecret)
+
+	// Initialize login brute-force protection, sharing counters through Redis when enabled
+	var loginAttempts auth.AttemptStore = auth.NewMemoryAttemptStore()
+	if redisClient != nil {
+		loginAttempts = auth.NewRedisAttemptStore(redisClient)
+	}
+	loginGuard := auth.NewLoginGuard(
+		loginAttempts,
+		auth.LockoutPolicy{
+			Threshold:   cfg.Lockout.MaxAccountFailures,
+			BaseLockout: cfg.Lockout.BaseDuration,
+			MaxLockout:  cfg.Lockout.MaxDuration,
+			Window:      cfg.Lockout.FailureWindow,
+		},
+		auth.LockoutPolicy{
+			Threshold:   cfg.Lockout.MaxIPFailures,
+			BaseLockout: cfg.Lockout.BaseDuration,
+			MaxLockout:  cfg.Lockout.MaxDuration,
+			Window:      cfg.Lockout.FailureWindow,
+		},
+	)
+
+	// Initialize external OpenID Connect providers
+	oidcClient := &http.Client{Timeout: 10 * time.Second}
+	oidcProviders := make(map[string]*auth.OIDCProvider)
+	for _, providerCfg := range cfg.Auth.OIDCProviders {
+		oidcProviders[providerCfg.Name] = auth.NewOIDCProvider(providerCfg, oidcClient)
+		logger.Printf("Enabled sign-in with %s", providerCfg.Name)
+	}
+
+	// Initialize mailer; only development may fall back to logging messages
+	if cfg.Mailer.Backend == "" {
+		if cfg.Env != "development" {
+			logger.Fatal("MAILER_BACKEND must be set outside development")
+		}
+		cfg.Mailer.Backend = "log"
+	}
+	mail, err := mailer.New(cfg.Mailer, logger)
+	if err != nil {
+		logger.Fatalf("Mailer initialization failed: %v", err)
+	}
+	logger.Printf("Using %s mailer", cfg.Mailer.Backend)
+
+	// Initialize home timelines
+	timelines, err := feed.New(cfg.Feed, postStore, followStore, redisClient)
+	if err != nil {
+		logger.Fatalf("Feed initialization failed: %v", err)
+	}
+	logger.Printf("Using %s feed", cfg.Feed.Backend)
+
+	// Delete accounts once their deletion grace period has passed
+	if cfg.Account.DeletedPosts != "delete" && cfg.Account.DeletedPosts != "anonymize" {
+		logger.Fatalf("Invalid ACCOUNT_DELETED_POSTS %q: must be delete or anonymize", cfg.Account.DeletedPosts)
+	}
+	purger := account.NewPurger(userStore, followStore, auditStore, cacheService, timelines, cfg.Account.PurgeInterval, cfg.Account.DeletedPosts == "anonymize", logger)
+	purgerCtx, stopPurger := context.WithCancel(context.Background())
+	purgerDone := make(chan struct{})
+	go func() {
+		purger.Run(purgerCtx)
+		close(purgerDone)
+	}()
+
 	// Initialize rate limiter
 	rateLimiter := appMiddleware.NewFixedWindowRateLimiter(
 		cfg.RateLimiter.RequestsPerWindow,
@@ -94,13 +235,32 @@ func main() {
 		cfg,
 		logger,
 		authenticator,
+		keys,
 		cacheService,
 		userStore,
 		postStore,
+		refreshTokenStore,
+		apiKeyStore,
+		passwordResetStore,
+		auditStore,
+		mfaStore,
+		sessionStore,
+		userIdentityStore,
+		oauthStore,
+		commentStore,
+		reactionStore,
+		followStore,
+		revocations,
+		signer,
+		loginGuard,
+		oidcProviders,
+		projection,
+		mail,
+		timelines,
 	)
 
 	// Set up router with middleware
-	router := setupRouter(app, cfg, logger, authenticator, userStore, rateLimiter)
+	router := setupRouter(app, cfg, logger, authSchemes, sessionTracker, userStore, rateLimiter)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -148,6 +308,17 @@ func main() {
 			logger.Println("Shutdown timeout: some connections may have been dropped")
 		}
 
+		// Finish publishing posts to timelines
+		app.Wait()
+
+		// Write out pending session last-seen times
+		stopTracker()
+		<-trackerDone
+
+		// Stop purging accounts
+		stopPurger()
+		<-purgerDone
+
 		logger.Println("Server shutdown complete")
 	}
 }
@@ -157,7 +328,8 @@ func setupRouter(
 	app *handler.Application,
 	cfg config.Config,
 	logger *log.Logger,
-	authenticator *auth.JWTAuthenticator,
+	authSchemes []auth.Scheme,
+	sessionTracker *auth.SessionTracker,
 	userStore store.UserStore,
 	rateLimiter appMiddleware.RateLimiter,
 ) http.Handler {
@@ -179,32 +351,146 @@ func setupRouter(
 		r.Use(appMiddleware.RateLimiterMiddleware(rateLimiter, logger))
 	}
 
+	// Public key set for verifying access tokens
+	r.Get("/.well-known/jwks.json", app.JWKS)
+
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Public routes
 		r.Get("/health", app.HealthCheck)
 		r.Post("/users", app.RegisterUser)
 		r.Post("/auth/token", app.CreateToken)
+		r.Post("/auth/refresh", app.RefreshToken)
+		r.Post("/auth/mfa/verify", app.VerifyMFA)
+		r.Get("/auth/oidc/{provider}/login", app.OIDCLogin)
+		r.Get("/auth/oidc/{provider}/callback", app.OIDCCallback)
+		r.Post("/oauth/token", app.OAuthToken)
+		r.Post("/auth/password/forgot", app.ForgotPassword)
+		r.Post("/auth/password/reset", app.ResetPassword)
+		r.Post("/users/verify", app.VerifyEmail)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			// Apply auth middleware
-			r.Use(auth.Middleware(authenticator, userStore))
+			r.Use(auth.Middleware(userStore, authSchemes...))
+
+			// Record session activity
+			r.Use(sessionTracker.Middleware)
+
+			// Require the CSRF header on unsafe requests authenticated by session cookie
+			r.Use(auth.RequireCSRF(cfg.Session.CSRFCookieName))
+
+			// Auth routes
+			r.Post("/auth/logout", app.Logout)
 
-			// User routes
-			r.Get("/users/me", app.GetCurrentUser)
+			// Optionally restrict content changes to verified accounts
+			verified := func(next http.Handler) http.Handler { return next }
+			if cfg.Auth.RequireVerifiedEmail {
+				verified = auth.RequireVerifiedEmail
+			}
+
+			// Account routes are not available to third-party applications
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireFirstParty)
+
+				r.Post("/auth/logout-all", app.LogoutAll)
+
+				// User routes
+				r.Get("/users/me", app.GetCurrentUser)
+				r.Patch("/users/me", app.UpdateCurrentUser)
+				r.Put("/users/me/password", app.ChangePassword)
+				r.Delete("/users/me", app.DeleteCurrentUser)
+				r.Get("/users/me/export", app.ExportCurrentUser)
+				r.Post("/users/verify/resend", app.ResendVerificationEmail)
+
+				// Profile and directory routes
+				r.Get("/users", app.ListUsers)
+				r.Get("/users/{username}", app.GetUserProfile)
+
+				// Follow routes
+				r.With(verified).Post("/users/{id}/follow", app.FollowUser)
+				r.Delete("/users/{id}/follow", app.UnfollowUser)
+				r.Get("/users/{id}/followers", app.ListFollowers)
+				r.Get("/users/{id}/following", app.ListFollowing)
+				r.Route("/users/me/follow-requests", func(r chi.Router) {
+					r.Get("/", app.ListFollowRequests)
+					r.Post("/{id}/accept", app.AcceptFollowRequest)
+					r.Delete("/{id}", app.RejectFollowRequest)
+				})
+
+				// API key routes
+				r.Route("/users/me/api-keys", func(r chi.Router) {
+					r.Get("/", app.ListAPIKeys)
+					r.Post("/", app.CreateAPIKey)
+					r.Delete("/{id}", app.DeleteAPIKey)
+				})
+
+				// Session routes
+				r.Route("/users/me/sessions", func(r chi.Router) {
+					r.Get("/", app.ListSessions)
+					r.Delete("/{id}", app.DeleteSession)
+				})
+
+				// Two-factor authentication routes
+				r.Route("/users/me/mfa", func(r chi.Router) {
+					r.Post("/totp", app.EnrollTOTP)
+					r.Post("/totp/confirm", app.ConfirmTOTP)
+					r.Post("/totp/disable", app.DisableTOTP)
+					r.Post("/recovery-codes", app.RegenerateRecoveryCodes)
+				})
+
+				// OAuth2 consent and client registration routes
+				r.Get("/oauth/authorize", app.GetAuthorization)
+				r.Post("/oauth/authorize", app.Authorize)
+				r.Route("/oauth/clients", func(r chi.Router) {
+					r.Get("/", app.ListOAuthClients)
+					r.Post("/", app.CreateOAuthClient)
+					r.Delete("/{id}", app.DeleteOAuthClient)
+				})
+
+				// Admin routes
+				r.Route("/admin/users", func(r chi.Router) {
+					r.Use(auth.RequirePermission(store.PermUsersManage))
+
+					r.Get("/", app.AdminListUsers)
+					r.Route("/{id}", func(r chi.Router) {
+						r.Get("/", app.AdminGetUser)
+						r.Post("/deactivate", app.AdminDeactivateUser)
+						r.Post("/reactivate", app.AdminReactivateUser)
+						r.Post("/password-reset", app.AdminForcePasswordReset)
+						r.Get("/posts", app.AdminListUserPosts)
+						r.Get("/sessions", app.AdminListUserSessions)
+					})
+				})
+			})
 
 			// Post routes
 			r.Route("/posts", func(r chi.Router) {
-				r.Get("/", app.ListPosts)
-				r.Post("/", app.CreatePost)
+				r.With(auth.RequireScope(auth.ScopePostsRead)).Get("/", app.ListPosts)
+				r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Post("/", app.CreatePost)
 
 				r.Route("/{id}", func(r chi.Router) {
-					r.Get("/", app.GetPost)
-					r.Put("/", app.UpdatePost)
-					r.Delete("/", app.DeletePost)
+					r.With(auth.RequireScope(auth.ScopePostsRead)).Get("/", app.GetPost)
+					r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Put("/", app.UpdatePost)
+					r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Delete("/", app.DeletePost)
+
+					r.With(auth.RequireScope(auth.ScopePostsRead)).Get("/comments", app.ListComments)
+					r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Post("/comments", app.CreateComment)
+
+					r.With(auth.RequireScope(auth.ScopePostsRead)).Get("/reactions", app.ListReactions)
+					r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Put("/reactions/{kind}", app.AddReaction)
+					r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Delete("/reactions/{kind}", app.RemoveReaction)
 				})
 			})
+
+			// Feed routes
+			r.With(auth.RequireScope(auth.ScopePostsRead)).Get("/feed", app.GetFeed)
+
+			// Comment routes
+			r.Route("/comments/{id}", func(r chi.Router) {
+				r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Put("/", app.UpdateComment)
+				r.With(auth.RequireScope(auth.ScopePostsWrite), verified).Delete("/", app.DeleteComment)
+			})
 		})
 	})
 
@@ -0,0 +1,152 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"social-api/internal/cache"
+	"social-api/internal/feed"
+	"social-api/internal/store"
+)
+
+// purgeBatchSize is the number of accounts deleted per store query
+const purgeBatchSize = 100
+
+// Purger permanently deletes accounts once their deletion grace period has
+// passed. Users can cancel a scheduled deletion until then by logging in.
+type Purger struct {
+	users          store.UserStore
+	follows        store.FollowStore
+	audit          store.AuditStore
+	cache          cache.Cache
+	timelines      feed.Feed
+	interval       time.Duration
+	anonymizePosts bool
+	logger         *log.Logger
+}
+
+// NewPurger creates a new purger checking for due accounts at the given
+// interval. If anonymizePosts is set, the posts and comments of deleted
+// accounts are kept under a placeholder user instead of being deleted. The
+// cache may be nil.
+func NewPurger(
+	users store.UserStore,
+	follows store.FollowStore,
+	audit store.AuditStore,
+	cache cache.Cache,
+	timelines feed.Feed,
+	interval time.Duration,
+	anonymizePosts bool,
+	logger *log.Logger,
+) *Purger {
+	return &Purger{
+		users:          users,
+		follows:        follows,
+		audit:          audit,
+		cache:          cache,
+		timelines:      timelines,
+		interval:       interval,
+		anonymizePosts: anonymizePosts,
+		logger:         logger,
+	}
+}
+
+// Run purges due accounts every interval until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.purge(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// purge deletes every account that is due, in batches. Failed deletions are
+// logged and retried on the next run.
+func (p *Purger) purge(ctx context.Context) {
+	for {
+		ids, err := p.users.ListDueForDeletion(ctx, purgeBatchSize)
+		if err != nil {
+			p.logger.Printf("Error listing accounts due for deletion: %v", err)
+			return
+		}
+
+		deleted := 0
+		for _, id := range ids {
+			// The follows are deleted with the account, so find the
+			// timelines holding its posts first
+			followerIDs, err := p.follows.ListFollowerIDs(ctx, id)
+			if err != nil {
+				p.logger.Printf("Error listing followers of account %d: %v", id, err)
+				continue
+			}
+
+			postIDs, err := p.users.DeleteScheduled(ctx, id, p.anonymizePosts)
+			if err != nil {
+				// The user cancelled the deletion meanwhile
+				if errors.Is(err, store.ErrNotFound) {
+					continue
+				}
+				p.logger.Printf("Error deleting account %d: %v", id, err)
+				continue
+			}
+			deleted++
+
+			p.evict(ctx, id, postIDs, followerIDs)
+
+			// The event outlives the user, so the ID is kept in its details
+			err = p.audit.Create(ctx, &store.AuditEvent{
+				Event: store.AuditAccountDeleted,
+				Details: map[string]interface{}{
+					"user_id":          id,
+					"posts_anonymized": p.anonymizePosts,
+				},
+			})
+			if err != nil {
+				p.logger.Printf("Error writing audit event: %v", err)
+			}
+		}
+
+		if deleted > 0 {
+			p.logger.Printf("Deleted %d accounts", deleted)
+		}
+
+		// Stop once the backlog is cleared, or when no progress was made so that
+		// failing accounts are not retried in a tight loop
+		if len(ids) < purgeBatchSize || deleted == 0 {
+			return
+		}
+	}
+}
+
+// evict removes a deleted account and its posts from the cache, and discards
+// the timelines they appeared in so that they are rebuilt without them
+func (p *Purger) evict(ctx context.Context, userID int64, postIDs, followerIDs []int64) {
+	if p.cache != nil {
+		err := p.cache.Delete(ctx, cache.UserKey(userID))
+		if err != nil {
+			p.logger.Printf("Error deleting user %d from cache: %v", userID, err)
+		}
+
+		for _, postID := range postIDs {
+			err = p.cache.Delete(ctx, cache.PostKey(postID))
+			if err != nil {
+				p.logger.Printf("Error deleting post %d from cache: %v", postID, err)
+			}
+		}
+	}
+
+	for _, followerID := range append(followerIDs, userID) {
+		err := p.timelines.Invalidate(ctx, followerID)
+		if err != nil {
+			p.logger.Printf("Error invalidating timeline of user %d: %v", followerID, err)
+		}
+	}
+}
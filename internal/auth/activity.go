@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"social-api/internal/store"
+)
+
+// SessionTracker records when login sessions were last seen. Sightings are
+// buffered in memory and written in batches, so tracking costs one write per
+// flush interval instead of one per request.
+type SessionTracker struct {
+	store    store.SessionStore
+	interval time.Duration
+	logger   *log.Logger
+
+	mu      sync.Mutex
+	pending map[int64]time.Time
+}
+
+// NewSessionTracker creates a new session tracker flushing at the given interval
+func NewSessionTracker(sessionStore store.SessionStore, interval time.Duration, logger *log.Logger) *SessionTracker {
+	return &SessionTracker{
+		store:    sessionStore,
+		interval: interval,
+		logger:   logger,
+		pending:  make(map[int64]time.Time),
+	}
+}
+
+// Touch records that a session was seen now
+func (t *SessionTracker) Touch(sessionID int64) {
+	t.mu.Lock()
+	t.pending[sessionID] = time.Now()
+	t.mu.Unlock()
+}
+
+// Middleware records the session of every authenticated request. It must be
+// mounted after the authentication middleware.
+func (t *SessionTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if identity, ok := GetIdentityFromContext(r.Context()); ok && identity.SessionID != 0 {
+			t.Touch(identity.SessionID)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run flushes pending sightings every interval until ctx is cancelled,
+// then flushes one last time
+func (t *SessionTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.flush(ctx)
+		case <-ctx.Done():
+			// Final flush with a fresh context, since ctx is already cancelled
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			t.flush(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+// flush writes pending sightings to the store. Last-seen times are best
+// effort, so a failed batch is logged and dropped.
+func (t *SessionTracker) flush(ctx context.Context) {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = make(map[int64]time.Time)
+	t.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	err := t.store.TouchLastSeen(ctx, batch)
+	if err != nil {
+		t.logger.Printf("Error recording last-seen time of %d sessions: %v", len(batch), err)
+	}
+}
@@ -9,13 +9,95 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("expired token")
+	ErrRevokedToken = errors.New("revoked token")
 )
 
+// Token purposes. Access tokens carry no purpose; any other purpose limits
+// the token to a single step such as completing a two-factor login.
+const (
+	PurposeAccess     = ""
+	PurposeMFAPending = "mfa_pending"
+)
+
+// Claims holds the validated claims of an access token
+type Claims struct {
+	UserID  int64
+	TokenID string
+	Purpose string
+
+	// SessionID is the session of the login the token was issued for, or zero
+	SessionID int64
+
+	// ClientID is the OAuth2 client the token was issued to, and Scopes what
+	// it may access. Both are empty for first-party tokens.
+	ClientID  string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IsAccess reports whether the token is a full access token
+func (c *Claims) IsAccess() bool {
+	return c.Purpose == PurposeAccess
+}
+
+// IsDelegated reports whether the token was issued to a third-party application
+func (c *Claims) IsDelegated() bool {
+	return c.ClientID != ""
+}
+
+// HasScope reports whether the token grants a scope. First-party tokens
+// are not limited by scopes.
+func (c *Claims) HasScope(scope string) bool {
+	if !c.IsDelegated() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenOption customizes a generated token
+type TokenOption func(*tokenOptions)
+
+// tokenOptions holds the optional settings of a generated token
+type tokenOptions struct {
+	purpose   string
+	sessionID int64
+	clientID  string
+	scopes    []string
+}
+
+// WithPurpose restricts a token to the given purpose
+func WithPurpose(purpose string) TokenOption {
+	return func(o *tokenOptions) {
+		o.purpose = purpose
+	}
+}
+
+// WithSessionID ties a token to a login session, so revoking the session revokes the token
+func WithSessionID(sessionID int64) TokenOption {
+	return func(o *tokenOptions) {
+		o.sessionID = sessionID
+	}
+}
+
+// WithClient issues a token to an OAuth2 client, limited to the given scopes
+func WithClient(clientID string, scopes []string) TokenOption {
+	return func(o *tokenOptions) {
+		o.clientID = clientID
+		o.scopes = scopes
+	}
+}
+
 // Authenticator defines the interface for authentication operations
 type Authenticator interface {
 	// GenerateToken generates a token for a user
-	GenerateToken(userID int64, expiry time.Duration) (string, error)
+	GenerateToken(userID int64, expiry time.Duration, opts ...TokenOption) (string, error)
 
-	// ValidateToken validates a token and returns the user ID
-	ValidateToken(token string) (int64, error)
+	// ValidateToken validates a token and returns its claims
+	ValidateToken(token string) (*Claims, error)
 }
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -10,37 +11,64 @@ import (
 
 // JWTAuthenticator implements the Authenticator interface using JWT
 type JWTAuthenticator struct {
-	secret string
-	iss    string
-	aud    string
+	keys *KeySet
+	iss  string
+	aud  string
 }
 
 // NewJWTAuthenticator creates a new JWT authenticator
-func NewJWTAuthenticator(secret, issuer, audience string) *JWTAuthenticator {
+func NewJWTAuthenticator(keys *KeySet, issuer, audience string) *JWTAuthenticator {
 	return &JWTAuthenticator{
-		secret: secret,
-		iss:    issuer,
-		aud:    audience,
+		keys: keys,
+		iss:  issuer,
+		aud:  audience,
 	}
 }
 
 // GenerateToken creates a JWT token for a user
-func (a *JWTAuthenticator) GenerateToken(userID int64, expiry time.Duration) (string, error) {
+func (a *JWTAuthenticator) GenerateToken(userID int64, expiry time.Duration, opts ...TokenOption) (string, error) {
+	// Apply token options
+	var options tokenOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Generate a unique token ID so the token can be revoked
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	// Create token claims
 	claims := jwt.MapClaims{
 		"sub": userID,
+		"jti": tokenID,
 		"exp": time.Now().Add(expiry).Unix(),
 		"iat": time.Now().Unix(),
 		"nbf": time.Now().Unix(),
 		"iss": a.iss,
 		"aud": a.aud,
 	}
+	if options.purpose != PurposeAccess {
+		claims["purpose"] = options.purpose
+	}
+	if options.sessionID != 0 {
+		claims["sid"] = options.sessionID
+	}
+	if options.clientID != "" {
+		claims["client_id"] = options.clientID
+		claims["scope"] = FormatScope(options.scopes)
+	}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Create token with claims, identifying the signing key in the header
+	key := a.keys.SigningKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 
-	// Sign token with secret
-	signedToken, err := token.SignedString([]byte(a.secret))
+	// Sign token with the signing key
+	signedToken, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
@@ -48,42 +76,52 @@ func (a *JWTAuthenticator) GenerateToken(userID int64, expiry time.Duration) (st
 	return signedToken, nil
 }
 
-// ValidateToken validates a JWT token and returns the user ID
-func (a *JWTAuthenticator) ValidateToken(tokenString string) (int64, error) {
+// ValidateToken validates a JWT token and returns its claims
+func (a *JWTAuthenticator) ValidateToken(tokenString string) (*Claims, error) {
 	// Parse token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// Look up the verification key by its ID
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.keys.VerificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+
+		// Validate the signing method matches the key
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(a.secret), nil
+		return key.verifyKey, nil
 	},
 		jwt.WithExpirationRequired(),
 		jwt.WithAudience(a.aud),
 		jwt.WithIssuer(a.iss),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithValidMethods(a.keys.Methods()),
 	)
 
 	// Handle parsing errors
 	if err != nil {
-		return 0, ErrInvalidToken
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
 	}
 
 	// Validate token
 	if !token.Valid {
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// Extract claims
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// Extract user ID from subject claim
 	sub, ok := claims["sub"]
 	if !ok {
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// Convert subject to string based on type
@@ -94,14 +132,45 @@ func (a *JWTAuthenticator) ValidateToken(tokenString string) (int64, error) {
 	case string:
 		userIDStr = v
 	default:
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// Parse user ID as int64
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
+	}
+
+	// Extract token ID
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// Extract optional purpose, session ID and OAuth2 client
+	purpose, _ := claims["purpose"].(string)
+	sessionID, _ := claims["sid"].(float64)
+	clientID, _ := claims["client_id"].(string)
+	scope, _ := claims["scope"].(string)
+
+	// Extract timestamps
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return nil, ErrInvalidToken
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, ErrInvalidToken
 	}
 
-	return userID, nil
+	return &Claims{
+		UserID:    userID,
+		TokenID:   tokenID,
+		Purpose:   purpose,
+		SessionID: int64(sessionID),
+		ClientID:  clientID,
+		Scopes:    ParseScope(scope),
+		IssuedAt:  issuedAt.Time,
+		ExpiresAt: expiresAt.Time,
+	}, nil
 }
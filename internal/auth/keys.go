@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a single access token signing or verification key
+type Key struct {
+	// ID is the key ID carried in the token's kid header
+	ID string
+
+	// Method is the JWT signing method used with this key
+	Method jwt.SigningMethod
+
+	// signKey is the private key or HMAC secret; nil for verification-only keys
+	signKey interface{}
+
+	// verifyKey is the public key or HMAC secret
+	verifyKey interface{}
+
+	// public is the public key published in the JWKS; nil for HMAC keys
+	public crypto.PublicKey
+}
+
+// KeySet holds the active signing key and every key accepted for verification.
+// Keeping retired keys in the verification set allows rotation without
+// invalidating tokens that are still in flight.
+type KeySet struct {
+	signing      *Key
+	verification map[string]*Key
+}
+
+// NewHMACKeySet creates a key set that signs and verifies with a shared HS256 secret
+func NewHMACKeySet(secret string) *KeySet {
+	key := &Key{
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+
+	return &KeySet{
+		signing:      key,
+		verification: map[string]*Key{key.ID: key},
+	}
+}
+
+// LoadKeySet creates an asymmetric key set from PEM files. The signing key
+// file must hold a private key; verification key files may hold public or
+// private keys. The algorithm (RS256, ES256/384/512 or EdDSA) is derived from
+// the key type and the key ID is the key's RFC 7638 JWK thumbprint.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	// Load signing key
+	signing, err := loadKeyFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("signing key: %w", err)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("signing key: %s does not contain a private key", signingKeyFile)
+	}
+
+	keys := &KeySet{
+		signing:      signing,
+		verification: map[string]*Key{signing.ID: signing},
+	}
+
+	// Load additional verification keys
+	for _, file := range verificationKeyFiles {
+		key, err := loadKeyFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("verification key: %w", err)
+		}
+		keys.verification[key.ID] = key
+	}
+
+	return keys, nil
+}
+
+// SigningKey returns the key used to sign new tokens
+func (ks *KeySet) SigningKey() *Key {
+	return ks.signing
+}
+
+// VerificationKey returns the verification key with the given ID
+func (ks *KeySet) VerificationKey(id string) (*Key, bool) {
+	key, ok := ks.verification[id]
+	return key, ok
+}
+
+// Methods returns the names of every signing method accepted for verification
+func (ks *KeySet) Methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.verification {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// loadKeyFile reads a PEM encoded key and builds a Key from it
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	// Parse private or public key
+	var private crypto.Signer
+	var public crypto.PublicKey
+	switch block.Type {
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		signer, ok := k.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key type %T", path, k)
+		}
+		private = signer
+	case "RSA PRIVATE KEY":
+		k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		private = k
+	case "EC PRIVATE KEY":
+		k, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		private = k
+	case "PUBLIC KEY":
+		k, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		public = k
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block type %q", path, block.Type)
+	}
+
+	if private != nil {
+		public = private.Public()
+	}
+
+	return newKey(private, public)
+}
+
+// newKey builds a Key from a public key and an optional private key
+func newKey(private crypto.Signer, public crypto.PublicKey) (*Key, error) {
+	key := &Key{public: public}
+
+	// Select signing method from key type
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = pub
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		key.verifyKey = pub
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.verifyKey = pub
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+
+	if private != nil {
+		key.signKey = private
+	}
+
+	// Derive key ID from the JWK thumbprint
+	jwk, err := newJWK(key)
+	if err != nil {
+		return nil, err
+	}
+	key.ID, err = jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lockout scopes
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+)
+
+// AttemptStore keeps failed login counters and lockouts
+type AttemptStore interface {
+	// Increment records a failure for key and returns the failure count.
+	// The counter expires after window without further failures.
+	Increment(ctx context.Context, key string, window time.Duration) (int, error)
+
+	// Lock locks key until the given time
+	Lock(ctx context.Context, key string, until time.Time) error
+
+	// LockedUntil returns when the lock on key expires, or the zero time if key is not locked
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+
+	// Reset clears the failure counter for key
+	Reset(ctx context.Context, key string) error
+}
+
+// LockoutPolicy configures when repeated failures lock a key
+type LockoutPolicy struct {
+	// Threshold is the number of failures that triggers the first lockout
+	Threshold int
+
+	// BaseLockout is the first lockout duration; each further failure doubles it
+	BaseLockout time.Duration
+
+	// MaxLockout caps the lockout duration
+	MaxLockout time.Duration
+
+	// Window is how long failures are remembered
+	Window time.Duration
+}
+
+// lockoutFor returns the lockout duration after the given number of failures,
+// or zero if the threshold has not been reached
+func (p LockoutPolicy) lockoutFor(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := p.Threshold; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+
+	return lockout
+}
+
+// Lockout describes an active lockout
+type Lockout struct {
+	Scope      string
+	Failures   int
+	RetryAfter time.Duration
+}
+
+// LoginGuard protects the login endpoint against brute force attacks by
+// tracking failures per account and per client IP, with progressively longer
+// lockouts as failures continue
+type LoginGuard struct {
+	attempts AttemptStore
+	account  LockoutPolicy
+	ip       LockoutPolicy
+}
+
+// NewLoginGuard creates a new login guard
+func NewLoginGuard(attempts AttemptStore, account, ip LockoutPolicy) *LoginGuard {
+	return &LoginGuard{
+		attempts: attempts,
+		account:  account,
+		ip:       ip,
+	}
+}
+
+// Check returns the active lockout for an account or IP, or nil if login may be attempted
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) (*Lockout, error) {
+	for _, k := range g.keys(email, ip) {
+		until, err := g.attempts.LockedUntil(ctx, k.key)
+		if err != nil {
+			return nil, err
+		}
+		if retryAfter := time.Until(until); retryAfter > 0 {
+			return &Lockout{Scope: k.scope, RetryAfter: retryAfter}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Fail records a failed login and returns the lockout it triggered, if any
+func (g *LoginGuard) Fail(ctx context.Context, email, ip string) (*Lockout, error) {
+	var triggered *Lockout
+	for _, k := range g.keys(email, ip) {
+		failures, err := g.attempts.Increment(ctx, k.key, k.policy.Window)
+		if err != nil {
+			return nil, err
+		}
+
+		lockout := k.policy.lockoutFor(failures)
+		if lockout == 0 {
+			continue
+		}
+
+		err = g.attempts.Lock(ctx, k.key, time.Now().Add(lockout))
+		if err != nil {
+			return nil, err
+		}
+		if triggered == nil {
+			triggered = &Lockout{Scope: k.scope, Failures: failures, RetryAfter: lockout}
+		}
+	}
+
+	return triggered, nil
+}
+
+// Succeed clears the account's failure counter after a successful login.
+// The IP counter is left alone so that one valid account cannot be used to
+// reset the counter while guessing passwords for others.
+func (g *LoginGuard) Succeed(ctx context.Context, email string) error {
+	return g.attempts.Reset(ctx, accountKey(email))
+}
+
+// guardKey pairs an attempt store key with its scope and policy
+type guardKey struct {
+	key    string
+	scope  string
+	policy LockoutPolicy
+}
+
+// keys returns the account and IP keys for a login attempt
+func (g *LoginGuard) keys(email, ip string) []guardKey {
+	return []guardKey{
+		{key: accountKey(email), scope: LockoutScopeAccount, policy: g.account},
+		{key: "login:ip:" + ip, scope: LockoutScopeIP, policy: g.ip},
+	}
+}
+
+// accountKey returns the attempt store key for an account
+func accountKey(email string) string {
+	return "login:account:" + strings.ToLower(email)
+}
+
+// memoryEvictInterval is how often MemoryAttemptStore sweeps out expired entries
+const memoryEvictInterval = time.Minute
+
+// MemoryAttemptStore implements AttemptStore in process memory.
+// Counters are not shared between instances.
+type MemoryAttemptStore struct {
+	mu        sync.Mutex
+	entries   map[string]*attemptEntry
+	nextEvict time.Time
+}
+
+// attemptEntry holds the counter and lock for a key
+type attemptEntry struct {
+	failures    int
+	expiry      time.Time
+	lockedUntil time.Time
+}
+
+// NewMemoryAttemptStore creates a new in-memory attempt store
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		entries: make(map[string]*attemptEntry),
+	}
+}
+
+// Increment records a failure for key
+func (s *MemoryAttemptStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.evictExpired(now)
+
+	// An expired entry may not have been swept out yet
+	entry, exists := s.entries[key]
+	if !exists || now.After(entry.expiry) {
+		entry = &attemptEntry{}
+		s.entries[key] = entry
+	}
+
+	entry.failures++
+	entry.expiry = now.Add(window)
+
+	return entry.failures, nil
+}
+
+// Lock locks key until the given time
+func (s *MemoryAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists {
+		entry = &attemptEntry{expiry: until}
+		s.entries[key] = entry
+	}
+
+	entry.lockedUntil = until
+	if entry.expiry.Before(until) {
+		entry.expiry = until
+	}
+
+	return nil
+}
+
+// LockedUntil returns when the lock on key expires
+func (s *MemoryAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists {
+		return time.Time{}, nil
+	}
+
+	return entry.lockedUntil, nil
+}
+
+// Reset clears the failure counter for key
+func (s *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// evictExpired removes entries whose window and lock have both passed. It
+// sweeps at most once per memoryEvictInterval, so that the cost of a failed
+// login does not grow with the number of keys. The caller must hold the lock.
+func (s *MemoryAttemptStore) evictExpired(now time.Time) {
+	if now.Before(s.nextEvict) {
+		return
+	}
+	s.nextEvict = now.Add(memoryEvictInterval)
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiry) {
+			delete(s.entries, key)
+		}
+	}
+}
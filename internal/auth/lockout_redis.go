@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisAttemptStore implements AttemptStore using Redis, so counters are
+// shared by every API instance
+type RedisAttemptStore struct {
+	client *redis.Client
+}
+
+// NewRedisAttemptStore creates a new Redis attempt store
+func NewRedisAttemptStore(client *redis.Client) *RedisAttemptStore {
+	return &RedisAttemptStore{
+		client: client,
+	}
+}
+
+// Increment records a failure for key
+func (s *RedisAttemptStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, key+":failures")
+	pipe.Expire(ctx, key+":failures", window)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(incr.Val()), nil
+}
+
+// Lock locks key until the given time
+func (s *RedisAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.client.Set(ctx, key+":locked", until.UnixMilli(), ttl).Err()
+}
+
+// LockedUntil returns when the lock on key expires
+func (s *RedisAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	millis, err := s.client.Get(ctx, key+":locked").Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(millis), nil
+}
+
+// Reset clears the failure counter for key
+func (s *RedisAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key+":failures").Err()
+}
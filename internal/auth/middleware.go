@@ -3,8 +3,8 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 
 	"social-api/internal/model"
 	"social-api/internal/store"
@@ -16,49 +16,39 @@ type contextKey string
 // userContextKey is the key for storing the user in the request context
 const userContextKey = contextKey("user")
 
-// Middleware is the authentication middleware
-func Middleware(authenticator *JWTAuthenticator, userStore store.UserStore) func(http.Handler) http.Handler {
+// identityContextKey is the key for storing the authenticated identity in the request context
+const identityContextKey = contextKey("identity")
+
+// Middleware is the authentication middleware. Schemes are tried in order;
+// the first one that finds credentials in the request decides the outcome,
+// and the request is tagged with that scheme.
+func Middleware(userStore store.UserStore, schemes ...Scheme) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
-					Error: "authorization header is required",
-				})
-				return
+			// Find the first scheme with credentials in the request
+			var identity *Identity
+			for _, scheme := range schemes {
+				id, err := scheme.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					writeAuthError(w, err)
+					return
+				}
+				identity = id
+				break
 			}
 
-			// Check for Bearer token
-			headerParts := strings.Split(authHeader, " ")
-			if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+			if identity == nil {
 				model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
-					Error: "authorization header format must be Bearer {token}",
+					Error: "authentication credentials are required",
 				})
 				return
 			}
 
-			// Get token
-			token := headerParts[1]
-
-			// Validate token
-			userID, err := authenticator.ValidateToken(token)
-			if err != nil {
-				switch {
-				case errors.Is(err, ErrExpiredToken):
-					model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
-						Error: "token has expired",
-					})
-				default:
-					model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
-						Error: "invalid authentication token",
-					})
-				}
-				return
-			}
-
 			// Get user from store
-			user, err := userStore.GetByID(r.Context(), userID)
+			user, err := userStore.GetByID(r.Context(), identity.UserID)
 			if err != nil {
 				if errors.Is(err, store.ErrNotFound) {
 					model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
@@ -80,8 +70,17 @@ func Middleware(authenticator *JWTAuthenticator, userStore store.UserStore) func
 				return
 			}
 
-			// Add user to context
+			// Accounts scheduled for deletion are locked until the user logs in again
+			if user.DeletionScheduledAt != nil {
+				model.WriteJSON(w, http.StatusForbidden, model.ErrorResponse{
+					Error: "user account is scheduled for deletion; log in to cancel",
+				})
+				return
+			}
+
+			// Add user and identity to context
 			ctx := context.WithValue(r.Context(), userContextKey, user)
+			ctx = context.WithValue(ctx, identityContextKey, identity)
 
 			// Call next handler with updated context
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -89,12 +88,54 @@ func Middleware(authenticator *JWTAuthenticator, userStore store.UserStore) func
 	}
 }
 
+// writeAuthError writes the response for a scheme that rejected its credentials
+func writeAuthError(w http.ResponseWriter, err error) {
+	var message string
+	switch {
+	case errors.Is(err, ErrMalformedCredentials):
+		message = "authorization header format must be Bearer {token}"
+	case errors.Is(err, ErrExpiredToken):
+		message = "token has expired"
+	case errors.Is(err, ErrRevokedToken):
+		message = "token has been revoked"
+	case errors.Is(err, ErrInvalidToken):
+		message = "invalid authentication token"
+	case errors.Is(err, ErrInvalidCredentials):
+		message = "invalid authentication credentials"
+	default:
+		model.WriteJSON(w, http.StatusInternalServerError, model.ErrorResponse{
+			Error: "internal server error",
+		})
+		return
+	}
+
+	model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
+		Error: message,
+	})
+}
+
 // GetUserFromContext retrieves the user from the context
 func GetUserFromContext(ctx context.Context) (*store.User, bool) {
 	user, ok := ctx.Value(userContextKey).(*store.User)
 	return user, ok
 }
 
+// GetIdentityFromContext retrieves the authenticated identity from the context
+func GetIdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey).(*Identity)
+	return identity, ok
+}
+
+// GetClaimsFromContext retrieves the access token claims from the context.
+// It returns false for requests that were not authenticated with a bearer token.
+func GetClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	identity, ok := GetIdentityFromContext(ctx)
+	if !ok || identity.Claims == nil {
+		return nil, false
+	}
+	return identity.Claims, true
+}
+
 // RequireUser is a middleware that requires a user to be authenticated
 func RequireUser(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -108,3 +149,86 @@ func RequireUser(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r)
 	}
 }
+
+// RequirePermission is a middleware that requires the authenticated user to
+// hold a permission through one of their roles
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
+					Error: "unauthorized",
+				})
+				return
+			}
+
+			if !user.HasPermission(permission) {
+				model.WriteJSON(w, http.StatusForbidden, model.ErrorResponse{
+					Error: "insufficient permissions",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope is a middleware that requires tokens issued to third-party
+// applications to grant a scope. First-party credentials always pass.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r.Context())
+			if ok && !claims.HasScope(scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+				model.WriteJSON(w, http.StatusForbidden, model.ErrorResponse{
+					Error: fmt.Sprintf("token is missing the %s scope", scope),
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireFirstParty is a middleware that rejects tokens issued to
+// third-party applications, keeping account management to the user
+func RequireFirstParty(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetClaimsFromContext(r.Context())
+		if ok && claims.IsDelegated() {
+			model.WriteJSON(w, http.StatusForbidden, model.ErrorResponse{
+				Error: "this endpoint is not available to third-party applications",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireVerifiedEmail is a middleware that requires the authenticated user
+// to have verified their email address
+func RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := GetUserFromContext(r.Context())
+		if !ok {
+			model.WriteJSON(w, http.StatusUnauthorized, model.ErrorResponse{
+				Error: "unauthorized",
+			})
+			return
+		}
+
+		if !user.IsEmailVerified() {
+			model.WriteJSON(w, http.StatusForbidden, model.ErrorResponse{
+				Error: "email address must be verified",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+// OAuth2 scopes that third-party applications can be granted
+const (
+	ScopePostsRead  = "posts:read"
+	ScopePostsWrite = "posts:write"
+)
+
+// PKCEMethodS256 is the only supported PKCE code challenge method
+const PKCEMethodS256 = "S256"
+
+// oauthClientSecretPrefix marks OAuth2 client secrets so they are recognisable in secret scanners
+const oauthClientSecretPrefix = "cs_"
+
+// ContainsScopes reports whether every requested scope is among the granted scopes
+func ContainsScopes(granted, requested []string) bool {
+	for _, scope := range requested {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseScope splits a space-delimited scope parameter, dropping duplicates
+func ParseScope(scope string) []string {
+	scopes := []string{}
+	seen := make(map[string]bool)
+	for _, s := range strings.Fields(scope) {
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// FormatScope joins scopes into a space-delimited scope parameter
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// GenerateOAuthClient creates a client ID and, for confidential clients, a
+// client secret and its hash
+func GenerateOAuthClient(confidential bool) (clientID, secret, secretHash string, err error) {
+	clientID, err = newTokenID()
+	if err != nil {
+		return "", "", "", err
+	}
+	if !confidential {
+		return clientID, "", "", nil
+	}
+
+	token, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	secret = oauthClientSecretPrefix + token
+	return clientID, secret, HashToken(secret), nil
+}
+
+// VerifyPKCE checks a code verifier against an S256 code challenge (RFC 7636)
+func VerifyPKCE(verifier, challenge string) bool {
+	if verifier == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(pkceChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// pkceChallenge derives the S256 code challenge of a code verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
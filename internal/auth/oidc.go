@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"social-api/internal/config"
+)
+
+// Signed token purposes for the OpenID Connect flow
+const (
+	PurposeOIDCState = "oidc_state"
+)
+
+// jwksRefreshInterval limits how often a provider's key set is refetched
+// when an ID token names an unknown key
+const jwksRefreshInterval = time.Minute
+
+// idTokenMethods are the signing algorithms accepted for provider ID tokens
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDC errors
+var (
+	// ErrOIDCProvider means the provider could not be reached or returned an unusable response
+	ErrOIDCProvider = errors.New("identity provider error")
+
+	// ErrInvalidIDToken means the provider's ID token failed verification
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// OIDCIdentity is the verified identity asserted by a provider's ID token
+type OIDCIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+// oidcDiscovery is the subset of the provider metadata document that is used
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider is a relying party client for a single OpenID Connect
+// provider, using the authorization code flow with PKCE. Provider metadata
+// and signing keys are fetched on first use and cached.
+type OIDCProvider struct {
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewOIDCProvider creates a new provider client
+func NewOIDCProvider(cfg config.OIDCProviderConfig, client *http.Client) *OIDCProvider {
+	return &OIDCProvider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+// Name returns the provider name
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL returns the provider URL that starts an authorization code flow
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the identity from the
+// verified ID token. The nonce must match the one sent with the request.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build token request
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	// Send token request
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: token endpoint returned %d %s %s", ErrOIDCProvider, status, tokens.Error, tokens.ErrorDescription)
+	}
+
+	return p.verifyIDToken(ctx, discovery, tokens.IDToken, nonce)
+}
+
+// verifyIDToken checks an ID token's signature, issuer, audience, expiry and nonce
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, rawIDToken, nonce string) (*OIDCIdentity, error) {
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, discovery, kid)
+	},
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithValidMethods(idTokenMethods),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// Extract claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	// Check nonce
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	// Extract identity
+	identity := &OIDCIdentity{}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+
+	// Some providers send email_verified as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+
+	return identity, nil
+}
+
+// getDiscovery returns the provider metadata, fetching it on first use
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.IssuerURL, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery oidcDiscovery
+	status, err := p.doJSON(req, &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery returned %d", ErrOIDCProvider, status)
+	}
+
+	// The issuer in the metadata must be the configured issuer
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch: got %q, want %q", ErrOIDCProvider, discovery.Issuer, p.cfg.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete provider metadata", ErrOIDCProvider)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// getKey returns the provider's signing key with the given ID, refetching
+// the key set if the key is unknown so that provider key rotation is picked up
+func (p *OIDCProvider) getKey(ctx context.Context, discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+
+	// Fetch key set
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set JWKS
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: key set returned %d", ErrOIDCProvider, status)
+	}
+
+	// Convert keys, skipping any that are not signing keys or cannot be used
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+
+	return key, nil
+}
+
+// doJSON sends a request and decodes a JSON response body, returning the status code
+func (p *OIDCProvider) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrOIDCProvider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrOIDCProvider, err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("%w: invalid JSON response: %v", ErrOIDCProvider, err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// GeneratePKCE creates a PKCE code verifier and its S256 code challenge (RFC 7636)
+func GeneratePKCE() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	return verifier, pkceChallenge(verifier), nil
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"social-api/internal/config"
+)
+
+const (
+	testOIDCClientID = "test-client"
+	testOIDCCode     = "test-code"
+	testOIDCKeyID    = "test-key"
+	testOIDCNonce    = "test-nonce"
+)
+
+// mockIssuer is a minimal OpenID Connect provider serving discovery, a key
+// set and a token endpoint that checks the PKCE verifier
+type mockIssuer struct {
+	server *httptest.Server
+
+	// key is published in the key set; signKey signs ID tokens and is the
+	// same key unless a test replaces it
+	key     ed25519.PrivateKey
+	signKey ed25519.PrivateKey
+
+	// challenge is the S256 PKCE challenge the authorization code was issued for
+	challenge string
+
+	// claims are the ID token claims
+	claims jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T, verifier string) *mockIssuer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	m := &mockIssuer{
+		key:       key,
+		signKey:   key,
+		challenge: pkceChallenge(verifier),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, oidcDiscovery{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JWKSURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, JWKS{Keys: []JWK{{
+			Kty: "OKP",
+			Kid: testOIDCKeyID,
+			Use: "sig",
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(m.key.Public().(ed25519.PublicKey)),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	m.claims = jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            testOIDCClientID,
+		"sub":            "subject-1",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"nonce":          testOIDCNonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	return m
+}
+
+// token redeems the authorization code if the PKCE verifier matches
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if r.PostForm.Get("code") != testOIDCCode || pkceChallenge(r.PostForm.Get("code_verifier")) != m.challenge {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, m.claims)
+	token.Header["kid"] = testOIDCKeyID
+	idToken, err := token.SignedString(m.signKey)
+	if err != nil {
+		writeTestJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeTestJSON(w, http.StatusOK, map[string]string{"id_token": idToken})
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	verifier, _, err := GeneratePKCE()
+	if err != nil {
+		t.Fatalf("generating PKCE verifier: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(m *mockIssuer)
+		verifier string
+		nonce    string
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			verifier: verifier,
+			nonce:    testOIDCNonce,
+		},
+		{
+			name:     "wrong PKCE verifier",
+			verifier: "wrong-verifier",
+			nonce:    testOIDCNonce,
+			wantErr:  ErrOIDCProvider,
+		},
+		{
+			name:     "nonce mismatch",
+			verifier: verifier,
+			nonce:    "other-nonce",
+			wantErr:  ErrInvalidIDToken,
+		},
+		{
+			name: "bad signature",
+			setup: func(m *mockIssuer) {
+				_, m.signKey, _ = ed25519.GenerateKey(rand.Reader)
+			},
+			verifier: verifier,
+			nonce:    testOIDCNonce,
+			wantErr:  ErrInvalidIDToken,
+		},
+		{
+			name: "wrong audience",
+			setup: func(m *mockIssuer) {
+				m.claims["aud"] = "other-client"
+			},
+			verifier: verifier,
+			nonce:    testOIDCNonce,
+			wantErr:  ErrInvalidIDToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newMockIssuer(t, verifier)
+			if tt.setup != nil {
+				tt.setup(issuer)
+			}
+
+			provider := NewOIDCProvider(config.OIDCProviderConfig{
+				Name:        "mock",
+				IssuerURL:   issuer.server.URL,
+				ClientID:    testOIDCClientID,
+				RedirectURL: "https://app.example.com/callback",
+				Scopes:      []string{"openid", "email"},
+			}, issuer.server.Client())
+
+			identity, err := provider.Exchange(context.Background(), testOIDCCode, tt.verifier, tt.nonce)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Exchange() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+
+			want := OIDCIdentity{Subject: "subject-1", Email: "alice@example.com", EmailVerified: true}
+			if *identity != want {
+				t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"social-api/internal/cache"
+	"social-api/internal/store"
+)
+
+// revocationNegativeTTL is how long a "not revoked" lookup is mirrored in the cache
+const revocationNegativeTTL = time.Minute
+
+// RevocationList tracks revoked access tokens. Postgres is the source of truth;
+// when a cache is configured, revocation state is mirrored there so that most
+// lookups do not reach the database.
+type RevocationList struct {
+	store       store.TokenRevocationStore
+	sessions    store.SessionStore
+	cache       cache.Cache
+	tokenExpiry time.Duration
+}
+
+// NewRevocationList creates a new revocation list. The cache may be nil.
+func NewRevocationList(
+	revocationStore store.TokenRevocationStore,
+	sessionStore store.SessionStore,
+	cache cache.Cache,
+	tokenExpiry time.Duration,
+) *RevocationList {
+	return &RevocationList{
+		store:       revocationStore,
+		sessions:    sessionStore,
+		cache:       cache,
+		tokenExpiry: tokenExpiry,
+	}
+}
+
+// Revoke revokes a single access token
+func (l *RevocationList) Revoke(ctx context.Context, claims *Claims) error {
+	err := l.store.Revoke(ctx, claims.TokenID, claims.UserID, claims.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	// Mirror to cache until the token would have expired anyway
+	if l.cache != nil {
+		ttl := time.Until(claims.ExpiresAt)
+		if ttl > 0 {
+			err = l.cache.Set(ctx, cache.RevokedTokenKey(claims.TokenID), true, ttl)
+			if err != nil {
+				// Drop any cached "not revoked" entry so the next lookup hits the store
+				_ = l.cache.Delete(ctx, cache.RevokedTokenKey(claims.TokenID))
+			}
+		}
+	}
+
+	return nil
+}
+
+// RevokeAll revokes every access token issued to a user up to now. Tokens
+// carry their issue time in whole seconds, so the cutoff is the start of the
+// next second: every token from the current second is revoked too, including
+// one issued just after this call.
+func (l *RevocationList) RevokeAll(ctx context.Context, userID int64) error {
+	now := time.Now().Truncate(time.Second).Add(time.Second)
+
+	err := l.store.RevokeAllForUser(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+
+	// Mirror to cache; after one token lifetime every older token has expired
+	if l.cache != nil {
+		err = l.cache.Set(ctx, cache.RevokedBeforeKey(userID), now, l.tokenExpiry)
+		if err != nil {
+			_ = l.cache.Delete(ctx, cache.RevokedBeforeKey(userID))
+		}
+	}
+
+	return nil
+}
+
+// RevokeSession revokes a login session belonging to a user, and with it
+// every access token issued for the session. It returns store.ErrNotFound
+// if the session does not exist or is already revoked.
+func (l *RevocationList) RevokeSession(ctx context.Context, sessionID, userID int64) error {
+	err := l.sessions.Revoke(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Mirror to cache; after one token lifetime every token of the session has expired
+	if l.cache != nil {
+		err = l.cache.Set(ctx, cache.RevokedSessionKey(sessionID), true, l.tokenExpiry)
+		if err != nil {
+			_ = l.cache.Delete(ctx, cache.RevokedSessionKey(sessionID))
+		}
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether an access token has been revoked, either
+// individually, through its session, or by a revoke-all for its user
+func (l *RevocationList) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	revoked, err := l.isTokenRevoked(ctx, claims)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	if claims.SessionID != 0 {
+		revoked, err = l.isSessionRevoked(ctx, claims)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	before, err := l.revokedBefore(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	// The cutoff is exclusive: tokens issued before it are revoked
+	return claims.IssuedAt.Before(before), nil
+}
+
+// isTokenRevoked checks the revocation list for a single token
+func (l *RevocationList) isTokenRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	key := cache.RevokedTokenKey(claims.TokenID)
+
+	// Try cache first
+	if l.cache != nil {
+		var revoked bool
+		if err := l.cache.Get(ctx, key, &revoked); err == nil {
+			return revoked, nil
+		}
+	}
+
+	// Fall back to the store
+	revoked, err := l.store.IsRevoked(ctx, claims.TokenID)
+	if err != nil {
+		return false, err
+	}
+
+	// Mirror the result
+	if l.cache != nil {
+		ttl := revocationNegativeTTL
+		if revoked {
+			ttl = time.Until(claims.ExpiresAt)
+		}
+		if ttl > 0 {
+			_ = l.cache.Set(ctx, key, revoked, ttl)
+		}
+	}
+
+	return revoked, nil
+}
+
+// isSessionRevoked checks whether the session a token was issued for has been revoked
+func (l *RevocationList) isSessionRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	key := cache.RevokedSessionKey(claims.SessionID)
+
+	// Try cache first
+	if l.cache != nil {
+		var revoked bool
+		if err := l.cache.Get(ctx, key, &revoked); err == nil {
+			return revoked, nil
+		}
+	}
+
+	// Fall back to the store
+	revoked, err := l.sessions.IsRevoked(ctx, claims.SessionID)
+	if err != nil {
+		return false, err
+	}
+
+	// Mirror the result
+	if l.cache != nil {
+		ttl := revocationNegativeTTL
+		if revoked {
+			ttl = l.tokenExpiry
+		}
+		_ = l.cache.Set(ctx, key, revoked, ttl)
+	}
+
+	return revoked, nil
+}
+
+// revokedBefore returns the revoke-all cutoff for a user
+func (l *RevocationList) revokedBefore(ctx context.Context, userID int64) (time.Time, error) {
+	key := cache.RevokedBeforeKey(userID)
+
+	// Try cache first
+	if l.cache != nil {
+		var before time.Time
+		if err := l.cache.Get(ctx, key, &before); err == nil {
+			return before, nil
+		}
+	}
+
+	// Fall back to the store
+	before, err := l.store.RevokedBefore(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Mirror the result
+	if l.cache != nil {
+		ttl := revocationNegativeTTL
+		if !before.IsZero() {
+			ttl = l.tokenExpiry
+		}
+		_ = l.cache.Set(ctx, key, before, ttl)
+	}
+
+	return before, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"social-api/internal/store"
+)
+
+// fakeTokenRevocationStore keeps revocation cutoffs in memory
+type fakeTokenRevocationStore struct {
+	store.TokenRevocationStore
+	cutoffs map[int64]time.Time
+}
+
+func (s *fakeTokenRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeTokenRevocationStore) RevokeAllForUser(ctx context.Context, userID int64, before time.Time) error {
+	s.cutoffs[userID] = before
+	return nil
+}
+
+func (s *fakeTokenRevocationStore) RevokedBefore(ctx context.Context, userID int64) (time.Time, error) {
+	return s.cutoffs[userID], nil
+}
+
+func TestRevokeAllSameSecond(t *testing.T) {
+	ctx := context.Background()
+	authenticator := NewJWTAuthenticator(NewHMACKeySet("test-secret"), "test", "test")
+	revocations := NewRevocationList(&fakeTokenRevocationStore{cutoffs: map[int64]time.Time{}}, nil, nil, 15*time.Minute)
+
+	// Issue a token and revoke all tokens, normally within the same second
+	token, err := authenticator.GenerateToken(1, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	claims, err := authenticator.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+
+	err = revocations.RevokeAll(ctx, 1)
+	if err != nil {
+		t.Fatalf("RevokeAll() error = %v", err)
+	}
+
+	revoked, err := revocations.IsRevoked(ctx, claims)
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if !revoked {
+		t.Errorf("token issued in the same second as RevokeAll is not revoked")
+	}
+
+	// Tokens issued from the next second on are valid
+	later := *claims
+	later.IssuedAt = claims.IssuedAt.Add(2 * time.Second)
+	revoked, err = revocations.IsRevoked(ctx, &later)
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if revoked {
+		t.Errorf("token issued after the cutoff second is revoked")
+	}
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"social-api/internal/store"
+)
+
+// Authentication scheme names
+const (
+	SchemeBearer  = "bearer"
+	SchemeAPIKey  = "api_key"
+	SchemeSession = "session"
+)
+
+// Scheme errors
+var (
+	// ErrNoCredentials means the request carries no credentials for a scheme,
+	// so the next scheme in the chain should be tried
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrMalformedCredentials means credentials are present but unparseable
+	ErrMalformedCredentials = errors.New("malformed credentials")
+
+	// ErrInvalidCredentials means credentials are present but not accepted
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity describes who a request was authenticated as and by which scheme
+type Identity struct {
+	UserID int64
+	Scheme string
+
+	// Claims is set when the request was authenticated with a bearer token
+	Claims *Claims
+
+	// SessionID is the login session behind the request: the session cookie,
+	// or the session a bearer token was issued for. It is zero for API keys.
+	SessionID int64
+}
+
+// Scheme authenticates requests using a single kind of credential
+type Scheme interface {
+	// Name returns the scheme name the request is tagged with
+	Name() string
+
+	// Authenticate returns the identity for the request, or ErrNoCredentials
+	// when the request does not carry credentials for this scheme
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// BearerScheme authenticates requests with a JWT in the Authorization header
+type BearerScheme struct {
+	authenticator Authenticator
+	revocations   *RevocationList
+}
+
+// NewBearerScheme creates a new bearer token scheme
+func NewBearerScheme(authenticator Authenticator, revocations *RevocationList) *BearerScheme {
+	return &BearerScheme{
+		authenticator: authenticator,
+		revocations:   revocations,
+	}
+}
+
+// Name returns the scheme name
+func (s *BearerScheme) Name() string {
+	return SchemeBearer
+}
+
+// Authenticate validates the bearer token and checks the revocation list
+func (s *BearerScheme) Authenticate(r *http.Request) (*Identity, error) {
+	// Get authorization header
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// Check for Bearer token
+	headerParts := strings.Split(authHeader, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return nil, ErrMalformedCredentials
+	}
+
+	// Validate token
+	claims, err := s.authenticator.ValidateToken(headerParts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	// Only access tokens authenticate requests; purpose-bound tokens such as
+	// pending two-factor tokens are accepted solely by their own endpoint
+	if !claims.IsAccess() {
+		return nil, ErrInvalidToken
+	}
+
+	// Check revocation list
+	revoked, err := s.revocations.IsRevoked(r.Context(), claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+
+	return &Identity{
+		UserID:    claims.UserID,
+		Scheme:    SchemeBearer,
+		Claims:    claims,
+		SessionID: claims.SessionID,
+	}, nil
+}
+
+// APIKeyValidator resolves an API key to the ID of the user that owns it
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key string) (int64, error)
+}
+
+// APIKeyScheme authenticates requests with an API key header
+type APIKeyScheme struct {
+	header    string
+	validator APIKeyValidator
+}
+
+// NewAPIKeyScheme creates a new API key scheme reading the given header
+func NewAPIKeyScheme(header string, validator APIKeyValidator) *APIKeyScheme {
+	return &APIKeyScheme{
+		header:    header,
+		validator: validator,
+	}
+}
+
+// Name returns the scheme name
+func (s *APIKeyScheme) Name() string {
+	return SchemeAPIKey
+}
+
+// Authenticate validates the API key header
+func (s *APIKeyScheme) Authenticate(r *http.Request) (*Identity, error) {
+	key := r.Header.Get(s.header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	userID, err := s.validator.ValidateAPIKey(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		UserID: userID,
+		Scheme: SchemeAPIKey,
+	}, nil
+}
+
+// SessionValidator resolves a session cookie value to an active session
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, token string) (*store.Session, error)
+}
+
+// SessionScheme authenticates requests with a session cookie
+type SessionScheme struct {
+	cookieName string
+	validator  SessionValidator
+}
+
+// NewSessionScheme creates a new session cookie scheme reading the given cookie
+func NewSessionScheme(cookieName string, validator SessionValidator) *SessionScheme {
+	return &SessionScheme{
+		cookieName: cookieName,
+		validator:  validator,
+	}
+}
+
+// Name returns the scheme name
+func (s *SessionScheme) Name() string {
+	return SchemeSession
+}
+
+// Authenticate validates the session cookie
+func (s *SessionScheme) Authenticate(r *http.Request) (*Identity, error) {
+	cookie, err := r.Cookie(s.cookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoCredentials
+	}
+
+	session, err := s.validator.ValidateSession(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		UserID:    session.UserID,
+		Scheme:    SchemeSession,
+		SessionID: session.ID,
+	}, nil
+}
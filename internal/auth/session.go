@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// CSRFHeader is the header that must echo the CSRF cookie on unsafe requests
+const CSRFHeader = "X-CSRF-Token"
+
+// SessionAuthenticator validates session cookies against a SessionStore
+type SessionAuthenticator struct {
+	store store.SessionStore
+}
+
+// NewSessionAuthenticator creates a new session authenticator
+func NewSessionAuthenticator(sessionStore store.SessionStore) *SessionAuthenticator {
+	return &SessionAuthenticator{
+		store: sessionStore,
+	}
+}
+
+// ValidateSession resolves a session token to an active session
+func (a *SessionAuthenticator) ValidateSession(ctx context.Context, token string) (*store.Session, error) {
+	// Look up session by hash
+	session, err := a.store.GetByHash(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Reject expired and revoked sessions
+	if !session.IsActive() {
+		return nil, ErrInvalidCredentials
+	}
+
+	return session, nil
+}
+
+// RequireCSRF is a middleware that protects session-authenticated requests
+// against cross-site request forgery using the double-submit cookie pattern:
+// unsafe methods must send the value of the CSRF cookie in the CSRFHeader.
+// Requests authenticated by other schemes are not affected, since browsers
+// never attach their credentials automatically.
+func RequireCSRF(cookieName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := GetIdentityFromContext(r.Context())
+			if !ok || identity.Scheme != SchemeSession || isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Compare the cookie with the header
+			cookie, err := r.Cookie(cookieName)
+			header := r.Header.Get(CSRFHeader)
+			if err != nil || cookie.Value == "" || header == "" ||
+				subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+				model.WriteJSON(w, http.StatusForbidden, model.ErrorResponse{
+					Error: "missing or invalid CSRF token",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isSafeMethod reports whether an HTTP method is read-only
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// opaqueTokenBytes is the number of random bytes in an opaque token
+const opaqueTokenBytes = 32
+
+// tokenIDBytes is the number of random bytes in a token ID (jti)
+const tokenIDBytes = 16
+
+// GenerateOpaqueToken creates a random URL-safe token and returns it with its hash.
+// Only the hash should be persisted; the token itself is handed to the client.
+func GenerateOpaqueToken() (string, string, error) {
+	b := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of an opaque token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTokenID creates a random hex-encoded token ID for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, tokenIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -79,3 +79,18 @@ func UserKey(userID int64) string {
 func PostKey(postID int64) string {
 	return fmt.Sprintf("post:%d", postID)
 }
+
+// RevokedTokenKey generates a cache key for an access token's revocation state
+func RevokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("revoked_token:%s", tokenID)
+}
+
+// RevokedBeforeKey generates a cache key for a user's token revocation cutoff
+func RevokedBeforeKey(userID int64) string {
+	return fmt.Sprintf("revoked_before:%d", userID)
+}
+
+// RevokedSessionKey generates a cache key for a session's revocation state
+func RevokedSessionKey(sessionID int64) string {
+	return fmt.Sprintf("revoked_session:%d", sessionID)
+}
@@ -3,17 +3,29 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultTokenSecret is the publicly known development value of AUTH_TOKEN_SECRET
+const DefaultTokenSecret = "your-super-secret-key-change-in-production"
+
 // Config holds the application configuration
 type Config struct {
 	Port        string
 	Env         string
+	BaseURL     string
 	DB          DBConfig
 	Redis       RedisConfig
 	Auth        AuthConfig
 	RateLimiter RateLimiterConfig
+	Lockout     LockoutConfig
+	Session     SessionConfig
+	Password    PasswordConfig
+	Projection  ProjectionConfig
+	Account     AccountConfig
+	Feed        FeedConfig
+	Mailer      MailerConfig
 }
 
 // DBConfig holds database configuration
@@ -39,10 +51,59 @@ type RedisConfig struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	TokenSecret   string
-	TokenIssuer   string
-	TokenAudience string
-	TokenExpiry   time.Duration
+	TokenSecret        string
+	TokenIssuer        string
+	TokenAudience      string
+	TokenExpiry        time.Duration
+	RefreshTokenExpiry time.Duration
+
+	// SigningKeyFile is a PEM private key (RSA, ECDSA or Ed25519). When set,
+	// tokens are signed asymmetrically and TokenSecret is not used.
+	SigningKeyFile string
+
+	// VerificationKeyFiles are additional PEM keys accepted when validating
+	// tokens, typically the public keys of recently rotated signing keys
+	VerificationKeyFiles []string
+
+	// SigningSecret signs out-of-band tokens such as email verification links
+	// and OIDC state cookies. It is separate from TokenSecret, which is unused
+	// once asymmetric keys are configured. Until the next release, TokenSecret
+	// is used instead when this is unset and TokenSecret is not the default.
+	SigningSecret string
+
+	// EmailVerificationExpiry is how long an email verification link stays valid
+	EmailVerificationExpiry time.Duration
+
+	// PasswordResetExpiry is how long a password reset token stays valid
+	PasswordResetExpiry time.Duration
+
+	// MFATokenExpiry is how long a user has to enter a two-factor code after their password
+	MFATokenExpiry time.Duration
+
+	// MFAIssuer is the account issuer shown in authenticator apps
+	MFAIssuer string
+
+	// OAuthCodeExpiry is how long an OAuth2 authorization code can be redeemed
+	OAuthCodeExpiry time.Duration
+
+	// RequireVerifiedEmail blocks unverified accounts from creating or changing content
+	RequireVerifiedEmail bool
+
+	// OIDCProviders are the external OpenID Connect providers users can sign in with
+	OIDCProviders []OIDCProviderConfig
+}
+
+// OIDCProviderConfig holds the configuration of an external OpenID Connect provider
+type OIDCProviderConfig struct {
+	// Name identifies the provider in URLs, e.g. /auth/oidc/{name}/login
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the callback URL registered with the provider
+	RedirectURL string
+	Scopes      []string
 }
 
 // RateLimiterConfig holds rate limiter configuration
@@ -52,11 +113,127 @@ type RateLimiterConfig struct {
 	WindowDuration    time.Duration
 }
 
+// LockoutConfig holds login brute-force protection configuration
+type LockoutConfig struct {
+	// MaxAccountFailures is the number of failed logins for one account before it is locked
+	MaxAccountFailures int
+
+	// MaxIPFailures is the number of failed logins from one IP before it is blocked
+	MaxIPFailures int
+
+	// BaseDuration is the first lockout duration; it doubles with each further failure
+	BaseDuration time.Duration
+
+	// MaxDuration caps the lockout duration
+	MaxDuration time.Duration
+
+	// FailureWindow is how long failed logins are remembered
+	FailureWindow time.Duration
+}
+
+// PasswordConfig holds password hashing configuration. Changing it upgrades
+// existing hashes as users next log in.
+type PasswordConfig struct {
+	// Algorithm is bcrypt or argon2id
+	Algorithm string
+
+	// BcryptCost is the bcrypt work factor
+	BcryptCost int
+
+	// Argon2Memory is the argon2id memory cost in KiB
+	Argon2Memory int
+
+	// Argon2Iterations is the argon2id time cost
+	Argon2Iterations int
+
+	// Argon2Parallelism is the number of argon2id lanes
+	Argon2Parallelism int
+}
+
+// ProjectionConfig holds which user fields are left out of responses,
+// depending on who is viewing the user. Field names are as in the JSON
+// responses, e.g. "email" or "bio". Email addresses are always hidden from
+// other users.
+type ProjectionConfig struct {
+	HiddenFromSelf   []string
+	HiddenFromAdmins []string
+	HiddenFromOthers []string
+}
+
+// AccountConfig holds account deletion configuration
+type AccountConfig struct {
+	// DeletionGracePeriod is how long a user has to cancel a requested
+	// account deletion by logging in again
+	DeletionGracePeriod time.Duration
+
+	// PurgeInterval is how often accounts past their grace period are deleted
+	PurgeInterval time.Duration
+
+	// DeletedPosts is what happens to a deleted user's posts and comments:
+	// "delete" or "anonymize", which keeps them under a placeholder user
+	DeletedPosts string
+}
+
+// FeedConfig holds home timeline configuration
+type FeedConfig struct {
+	// Backend is one of "read", which queries the followed users' posts on
+	// every request, "redis", which pushes new posts onto followers'
+	// timelines kept in Redis, or "hybrid", which only pushes the posts of
+	// authors with at most FanoutMaxFollowers followers and queries the rest
+	Backend string
+
+	// TimelineLength is the number of posts kept in each Redis timeline;
+	// older posts are queried from the database
+	TimelineLength int
+
+	// TimelineTTL is how long an unread Redis timeline is kept
+	TimelineTTL time.Duration
+
+	// FanoutMaxFollowers is the most followers an author can have for their
+	// posts to be pushed to timelines by the hybrid backend
+	FanoutMaxFollowers int
+}
+
+// SessionConfig holds browser session cookie configuration
+type SessionConfig struct {
+	CookieName     string
+	CSRFCookieName string
+	Expiry         time.Duration
+
+	// CookieSecure restricts the cookies to HTTPS; only disable it for local development
+	CookieSecure bool
+
+	// CookieSameSite is one of "strict", "lax" or "none"
+	CookieSameSite string
+	CookieDomain   string
+
+	// LastSeenFlushInterval is how often buffered last-seen times are written to the database
+	LastSeenFlushInterval time.Duration
+}
+
+// MailerConfig holds outgoing email configuration
+type MailerConfig struct {
+	// Backend is one of "smtp", "log" or "file". It must be set unless Env
+	// is development, where it defaults to "log", since the log and file
+	// backends expose the tokens in verification and reset emails.
+	Backend      string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+
+	// SMTPTimeout bounds connecting to the SMTP server and sending one message
+	SMTPTimeout time.Duration
+}
+
 // Load loads configuration from environment variables
 func Load() Config {
 	return Config{
-		Port: getEnv("PORT", "8080"),
-		Env:  getEnv("ENV", "development"),
+		Port:    getEnv("PORT", "8080"),
+		Env:     getEnv("ENV", "development"),
+		BaseURL: getEnv("APP_BASE_URL", "http://localhost:8080"),
 		DB: DBConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
 			Port:         getEnv("DB_PORT", "5432"),
@@ -76,17 +253,96 @@ func Load() Config {
 		},
 		Auth: AuthConfig{
 			// TODO: set AUTH_TOKEN_SECRET environment variable in production
-			TokenSecret:   getEnv("AUTH_TOKEN_SECRET", "your-super-secret-key-change-in-production"),
-			TokenIssuer:   getEnv("AUTH_TOKEN_ISSUER", "social-api"),
-			TokenAudience: getEnv("AUTH_TOKEN_AUDIENCE", "social-api-users"),
-			TokenExpiry:   getEnvAsDuration("AUTH_TOKEN_EXPIRY", 24*time.Hour),
+			TokenSecret:             getEnv("AUTH_TOKEN_SECRET", DefaultTokenSecret),
+			TokenIssuer:             getEnv("AUTH_TOKEN_ISSUER", "social-api"),
+			TokenAudience:           getEnv("AUTH_TOKEN_AUDIENCE", "social-api-users"),
+			TokenExpiry:             getEnvAsDuration("AUTH_TOKEN_EXPIRY", 15*time.Minute),
+			RefreshTokenExpiry:      getEnvAsDuration("AUTH_REFRESH_TOKEN_EXPIRY", 30*24*time.Hour),
+			SigningKeyFile:          getEnv("AUTH_SIGNING_KEY_FILE", ""),
+			VerificationKeyFiles:    getEnvAsSlice("AUTH_VERIFICATION_KEY_FILES", nil),
+			SigningSecret:           getEnv("AUTH_SIGNING_SECRET", ""),
+			EmailVerificationExpiry: getEnvAsDuration("AUTH_EMAIL_VERIFICATION_EXPIRY", 48*time.Hour),
+			PasswordResetExpiry:     getEnvAsDuration("AUTH_PASSWORD_RESET_EXPIRY", time.Hour),
+			MFATokenExpiry:          getEnvAsDuration("AUTH_MFA_TOKEN_EXPIRY", 5*time.Minute),
+			MFAIssuer:               getEnv("AUTH_MFA_ISSUER", "Social API"),
+			OAuthCodeExpiry:         getEnvAsDuration("AUTH_OAUTH_CODE_EXPIRY", time.Minute),
+			RequireVerifiedEmail:    getEnvAsBool("AUTH_REQUIRE_VERIFIED_EMAIL", false),
+			OIDCProviders:           loadOIDCProviders(),
 		},
 		RateLimiter: RateLimiterConfig{
 			Enabled:           getEnvAsBool("RATE_LIMITER_ENABLED", true),
 			RequestsPerWindow: getEnvAsInt("RATE_LIMITER_REQUESTS", 20),
 			WindowDuration:    getEnvAsDuration("RATE_LIMITER_WINDOW", 5*time.Second),
 		},
+		Lockout: LockoutConfig{
+			MaxAccountFailures: getEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+			MaxIPFailures:      getEnvAsInt("LOGIN_MAX_IP_FAILURES", 20),
+			BaseDuration:       getEnvAsDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+			MaxDuration:        getEnvAsDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+			FailureWindow:      getEnvAsDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		},
+		Password: PasswordConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+			Argon2Memory:      getEnvAsInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+			Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 2),
+		},
+		Projection: ProjectionConfig{
+			HiddenFromSelf:   getEnvAsSlice("USER_FIELDS_HIDDEN_FROM_SELF", nil),
+			HiddenFromAdmins: getEnvAsSlice("USER_FIELDS_HIDDEN_FROM_ADMINS", nil),
+			HiddenFromOthers: getEnvAsSlice("USER_FIELDS_HIDDEN_FROM_OTHERS", []string{"roles", "email_verified_at"}),
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: getEnvAsDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			PurgeInterval:       getEnvAsDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+			DeletedPosts:        getEnv("ACCOUNT_DELETED_POSTS", "delete"),
+		},
+		Feed: FeedConfig{
+			Backend:            getEnv("FEED_BACKEND", "read"),
+			TimelineLength:     getEnvAsInt("FEED_TIMELINE_LENGTH", 800),
+			TimelineTTL:        getEnvAsDuration("FEED_TIMELINE_TTL", 7*24*time.Hour),
+			FanoutMaxFollowers: getEnvAsInt("FEED_FANOUT_MAX_FOLLOWERS", 10000),
+		},
+		Session: SessionConfig{
+			CookieName:            getEnv("SESSION_COOKIE_NAME", "session"),
+			CSRFCookieName:        getEnv("SESSION_CSRF_COOKIE_NAME", "csrf_token"),
+			Expiry:                getEnvAsDuration("SESSION_EXPIRY", 7*24*time.Hour),
+			CookieSecure:          getEnvAsBool("SESSION_COOKIE_SECURE", true),
+			CookieSameSite:        getEnv("SESSION_COOKIE_SAMESITE", "lax"),
+			CookieDomain:          getEnv("SESSION_COOKIE_DOMAIN", ""),
+			LastSeenFlushInterval: getEnvAsDuration("SESSION_LAST_SEEN_FLUSH_INTERVAL", 30*time.Second),
+		},
+		Mailer: MailerConfig{
+			Backend:      getEnv("MAILER_BACKEND", ""),
+			From:         getEnv("MAILER_FROM", "Social API <no-reply@localhost>"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			FileDir:      getEnv("MAILER_FILE_DIR", "./mail"),
+			SMTPTimeout:  getEnvAsDuration("SMTP_TIMEOUT", 10*time.Second),
+		},
+	}
+}
+
+// loadOIDCProviders loads the providers named in AUTH_OIDC_PROVIDERS. Each
+// provider is configured with AUTH_OIDC_<NAME>_* variables.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvAsSlice("AUTH_OIDC_PROVIDERS", nil) {
+		prefix := "AUTH_OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       getEnvAsSlice(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		})
 	}
+
+	return providers
 }
 
 // Helper functions for environment variables
@@ -127,6 +383,23 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return value
 }
 
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	valueStr, exists := os.LookupEnv(key)
 	if !exists {
@@ -0,0 +1,45 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+
+	"social-api/internal/config"
+	"social-api/internal/store"
+)
+
+// Feed defines the interface for home timelines: the posts of a user and
+// the users they follow, newest first
+type Feed interface {
+	// Page retrieves up to limit posts from the viewer's timeline that are
+	// older than the post with ID before, or the newest posts if before is 0
+	Page(ctx context.Context, viewer *store.User, before int64, limit int) ([]*store.Post, error)
+
+	// Publish adds a new post to the timelines of its author and their followers
+	Publish(ctx context.Context, post *store.Post) error
+
+	// Invalidate discards a user's timeline after the users they follow change
+	Invalidate(ctx context.Context, userID int64) error
+}
+
+// New creates the feed selected by the configured backend
+func New(cfg config.FeedConfig, posts store.PostStore, follows store.FollowStore, client *redis.Client) (Feed, error) {
+	switch cfg.Backend {
+	case "read", "":
+		return NewReadFeed(posts), nil
+	case "redis", "hybrid":
+		if client == nil {
+			return nil, fmt.Errorf("feed backend %q requires Redis", cfg.Backend)
+		}
+
+		maxFollowers := -1
+		if cfg.Backend == "hybrid" {
+			maxFollowers = cfg.FanoutMaxFollowers
+		}
+		return NewRedisFeed(client, posts, follows, cfg.TimelineLength, cfg.TimelineTTL, maxFollowers), nil
+	default:
+		return nil, fmt.Errorf("unknown feed backend: %q", cfg.Backend)
+	}
+}
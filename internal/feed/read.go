@@ -0,0 +1,36 @@
+package feed
+
+import (
+	"context"
+
+	"social-api/internal/store"
+)
+
+// ReadFeed implements the Feed interface by querying the posts of the users
+// followed on every read. It needs no upkeep and suits users who follow few
+// accounts.
+type ReadFeed struct {
+	posts store.PostStore
+}
+
+// NewReadFeed creates a new fan-out-on-read feed
+func NewReadFeed(posts store.PostStore) *ReadFeed {
+	return &ReadFeed{
+		posts: posts,
+	}
+}
+
+// Page queries a page of the viewer's timeline
+func (f *ReadFeed) Page(ctx context.Context, viewer *store.User, before int64, limit int) ([]*store.Post, error) {
+	return f.posts.ListFeed(ctx, viewer.ID, nil, before, limit)
+}
+
+// Publish does nothing; new posts are found when timelines are read
+func (f *ReadFeed) Publish(ctx context.Context, post *store.Post) error {
+	return nil
+}
+
+// Invalidate does nothing; timelines are not stored
+func (f *ReadFeed) Invalidate(ctx context.Context, userID int64) error {
+	return nil
+}
@@ -0,0 +1,347 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"social-api/internal/store"
+)
+
+// pulledAuthorsKey is the set of authors whose posts the hybrid feed does
+// not push to timelines, because they had too many followers
+const pulledAuthorsKey = "timeline:pulled_authors"
+
+// fanoutBatchSize is the number of timelines updated per Redis round trip
+const fanoutBatchSize = 1000
+
+// Timeline marker scores. Every built timeline holds member 0 at rank 0,
+// scored markerTruncated once posts older than the timeline holds exist only
+// in the database.
+const (
+	markerBuilt     = 0
+	markerTruncated = -1
+)
+
+// pushScript adds a post to a timeline, unless the timeline has not been
+// built, and trims it to the newest ARGV[2] posts. The marker at rank 0 is
+// kept, and flagged as truncated if any posts were trimmed.
+var pushScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[1])
+if redis.call('ZREMRANGEBYRANK', KEYS[1], 1, -(tonumber(ARGV[2]) + 1)) > 0 then
+	redis.call('ZADD', KEYS[1], ARGV[3], 0)
+end
+return 1
+`)
+
+// RedisFeed implements the Feed interface with a precomputed timeline per
+// user, kept in a Redis sorted set of post IDs. New posts are pushed to the
+// timelines of the author's followers. A timeline is built from the database
+// when it is first read, and again after the user follows or unfollows
+// someone. Posts older than a timeline holds are queried from the database.
+//
+// In hybrid mode, the posts of authors with more than maxFollowers followers
+// are not pushed; they are queried from the database on every read and
+// merged into the timeline.
+type RedisFeed struct {
+	client       *redis.Client
+	posts        store.PostStore
+	follows      store.FollowStore
+	length       int
+	ttl          time.Duration
+	maxFollowers int
+}
+
+// NewRedisFeed creates a new Redis feed keeping up to length posts per
+// timeline. If maxFollowers is negative, the posts of all authors are pushed.
+func NewRedisFeed(client *redis.Client, posts store.PostStore, follows store.FollowStore, length int, ttl time.Duration, maxFollowers int) *RedisFeed {
+	return &RedisFeed{
+		client:       client,
+		posts:        posts,
+		follows:      follows,
+		length:       length,
+		ttl:          ttl,
+		maxFollowers: maxFollowers,
+	}
+}
+
+// Page reads a page of the viewer's timeline
+func (f *RedisFeed) Page(ctx context.Context, viewer *store.User, before int64, limit int) ([]*store.Post, error) {
+	userID := viewer.ID
+	err := f.build(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read post IDs from the timeline, reading further if some of the posts
+	// have been deleted or hidden from the viewer since
+	key := timelineKey(userID)
+	posts := []*store.Post{}
+	cursor := before
+	for len(posts) < limit {
+		max := "+inf"
+		if cursor > 0 {
+			max = "(" + strconv.FormatInt(cursor, 10)
+		}
+
+		count := limit - len(posts)
+		members, err := f.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+			Min:   "(0",
+			Max:   max,
+			Count: int64(count),
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		ids, err := parseIDs(members)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		found, err := f.posts.ListByIDs(ctx, viewer, ids)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, found...)
+
+		// Drop deleted and hidden posts from the timeline
+		if len(found) < len(ids) {
+			err = f.client.ZRem(ctx, key, missing(ids, found)...).Err()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		cursor = ids[len(ids)-1]
+		if len(ids) < count {
+			break
+		}
+	}
+
+	// A truncated timeline has dropped older posts, which are only in the database
+	if len(posts) < limit {
+		marker, err := f.client.ZScore(ctx, key, "0").Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+
+		if err == nil && marker == markerTruncated {
+			older, err := f.posts.ListFeed(ctx, userID, nil, cursor, limit-len(posts))
+			if err != nil {
+				return nil, err
+			}
+			posts = append(posts, older...)
+		}
+	}
+
+	// Merge in the posts of followed authors that are not pushed
+	if f.maxFollowers >= 0 {
+		members, err := f.client.SMembers(ctx, pulledAuthorsKey).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		authorIDs, err := parseIDs(members)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(authorIDs) > 0 {
+			pulled, err := f.posts.ListFeed(ctx, userID, authorIDs, before, limit)
+			if err != nil {
+				return nil, err
+			}
+			posts = merge(posts, pulled, limit)
+		}
+	}
+
+	return posts, nil
+}
+
+// Publish pushes a new post onto the timelines of its author and their followers
+func (f *RedisFeed) Publish(ctx context.Context, post *store.Post) error {
+	// Private and unlisted posts only appear in their author's timeline
+	if post.Visibility == store.VisibilityPrivate || post.Visibility == store.VisibilityUnlisted {
+		return f.push(ctx, post, []int64{post.UserID})
+	}
+
+	// In hybrid mode, widely followed authors' posts are queried when
+	// timelines are read instead
+	if f.maxFollowers >= 0 {
+		followers, _, err := f.follows.Counts(ctx, post.UserID)
+		if err != nil {
+			return err
+		}
+
+		if followers > f.maxFollowers {
+			return f.client.SAdd(ctx, pulledAuthorsKey, post.UserID).Err()
+		}
+	}
+
+	followerIDs, err := f.follows.ListFollowerIDs(ctx, post.UserID)
+	if err != nil {
+		return err
+	}
+
+	return f.push(ctx, post, append(followerIDs, post.UserID))
+}
+
+// push pushes a post onto the timelines of the given users in batches;
+// timelines that have not been built will include it when they are
+func (f *RedisFeed) push(ctx context.Context, post *store.Post, userIDs []int64) error {
+	for start := 0; start < len(userIDs); start += fanoutBatchSize {
+		end := start + fanoutBatchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+
+		pipe := f.client.Pipeline()
+		for _, userID := range userIDs[start:end] {
+			pushScript.Eval(ctx, pipe, []string{timelineKey(userID)}, post.ID, f.length, markerTruncated)
+		}
+
+		_, err := pipe.Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Invalidate deletes the user's timeline, so that it is built again when read
+func (f *RedisFeed) Invalidate(ctx context.Context, userID int64) error {
+	return f.client.Del(ctx, timelineKey(userID)).Err()
+}
+
+// build builds a user's timeline from the database unless it exists, and
+// extends its expiry
+func (f *RedisFeed) build(ctx context.Context, userID int64) error {
+	key := timelineKey(userID)
+
+	exists, err := f.client.Exists(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	if exists > 0 {
+		return f.client.Expire(ctx, key, f.ttl).Err()
+	}
+
+	posts, err := f.posts.ListFeed(ctx, userID, nil, 0, f.length)
+	if err != nil {
+		return err
+	}
+
+	// Mark the timeline as built, so that an empty timeline is not rebuilt
+	// on every read, and as truncated if older posts may not have fit
+	marker := markerBuilt
+	if len(posts) >= f.length {
+		marker = markerTruncated
+	}
+	members := []*redis.Z{{Score: float64(marker), Member: 0}}
+	for _, post := range posts {
+		members = append(members, &redis.Z{Score: float64(post.ID), Member: post.ID})
+	}
+
+	pipe := f.client.TxPipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, f.ttl)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Posts published while the timeline was being built were not pushed,
+	// since it did not exist yet; add any that are newer than the newest
+	// post it was built with
+	var newest int64
+	if len(posts) > 0 {
+		newest = posts[0].ID
+	}
+
+	latest, err := f.posts.ListFeed(ctx, userID, nil, 0, f.length)
+	if err != nil {
+		return err
+	}
+
+	pipe = f.client.Pipeline()
+	missed := 0
+	for i := len(latest) - 1; i >= 0; i-- {
+		if latest[i].ID > newest {
+			pushScript.Eval(ctx, pipe, []string{key}, latest[i].ID, f.length, markerTruncated)
+			missed++
+		}
+	}
+	if missed == 0 {
+		return nil
+	}
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// timelineKey generates the Redis key of a user's timeline
+func timelineKey(userID int64) string {
+	return fmt.Sprintf("timeline:%d", userID)
+}
+
+// parseIDs parses IDs read from Redis
+func parseIDs(members []string) ([]int64, error) {
+	ids := make([]int64, len(members))
+	for i, member := range members {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeline member %q: %w", member, err)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// missing returns the IDs that are not among the found posts
+func missing(ids []int64, found []*store.Post) []interface{} {
+	exists := make(map[int64]bool, len(found))
+	for _, post := range found {
+		exists[post.ID] = true
+	}
+
+	var gone []interface{}
+	for _, id := range ids {
+		if !exists[id] {
+			gone = append(gone, id)
+		}
+	}
+	return gone
+}
+
+// merge merges two lists of posts, both newest first, dropping duplicates,
+// and returns the newest limit posts
+func merge(a, b []*store.Post, limit int) []*store.Post {
+	seen := make(map[int64]bool, len(a)+len(b))
+	posts := make([]*store.Post, 0, len(a)+len(b))
+	for _, post := range append(a, b...) {
+		if !seen[post.ID] {
+			seen[post.ID] = true
+			posts = append(posts, post)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID > posts[j].ID })
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// DeleteCurrentUser handles the account deletion endpoint. The account is
+// signed out everywhere and deleted once the grace period has passed, unless
+// the user logs in again before then.
+func (app *Application) DeleteCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.DeleteAccountInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Check password
+	match, _, err := user.Password.Matches(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !match {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "password", Message: "Password is incorrect"},
+		})
+		return
+	}
+
+	// Schedule deletion
+	deleteAt := time.Now().Add(app.Config.Account.DeletionGracePeriod)
+	err = app.UserStore.ScheduleDeletion(r.Context(), user.ID, deleteAt)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Sign the user out everywhere
+	err = app.revokeAllSessions(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.invalidateUserCache(r.Context(), user.ID)
+	app.auditAccountEvent(r, store.AuditDeletionScheduled, user.ID)
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusAccepted, model.NewResponse(model.AccountDeletionResponse{
+		DeletionScheduledAt: deleteAt,
+	}))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// cancelAccountDeletion cancels a user's scheduled account deletion when they log in
+func (app *Application) cancelAccountDeletion(r *http.Request, user *store.User) error {
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+
+	// A concurrent login may have cancelled it already
+	err := app.UserStore.CancelDeletion(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	user.DeletionScheduledAt = nil
+
+	app.auditAccountEvent(r, store.AuditDeletionCancelled, user.ID)
+	return nil
+}
+
+// ExportCurrentUser handles the account data export endpoint.
+// Supports ?format=json (default) for a single JSON document or ?format=zip
+// for an archive of profile.json and posts.json.
+func (app *Application) ExportCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get format param
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "zip" {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "format", Message: "Must be json or zip"},
+		})
+		return
+	}
+
+	// Collect the user's data, with every field regardless of projection settings
+	export, err := app.accountExport(r.Context(), user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.auditAccountEvent(r, store.AuditDataExported, user.ID)
+
+	// Send the export as a download
+	filename := fmt.Sprintf("account-%d-%s", user.ID, export.ExportedAt.Format("20060102"))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if format == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		err = model.WriteJSON(w, http.StatusOK, export)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so errors from here on can only be logged
+	err = writeExportArchive(w, export)
+	if err != nil {
+		app.Logger.Printf("Error writing export archive for user %d: %v", user.ID, err)
+	}
+}
+
+// accountExport collects a user's profile and all of their posts
+func (app *Application) accountExport(ctx context.Context, user *store.User) (*model.AccountExport, error) {
+	all := func(string) bool { return true }
+
+	export := &model.AccountExport{
+		ExportedAt: time.Now().UTC(),
+		User:       projectUser(user, all),
+		Posts:      []model.PostResponse{},
+	}
+
+	// Page through the user's posts, oldest first
+	pagination := model.Pagination{
+		Page:     1,
+		PageSize: model.MaxPageSize,
+		Sort:     "asc",
+		SortBy:   "created_at",
+	}
+	for {
+		posts, totalCount, err := app.PostStore.List(ctx, user, pagination, model.PostFilter{UserID: &user.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		reactions, err := app.viewerReactions(ctx, user, posts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, post := range posts {
+			export.Posts = append(export.Posts, model.PostResponse{
+				ID:         post.ID,
+				Title:      post.Title,
+				Content:    post.Content,
+				Visibility: post.Visibility,
+				User:       export.User,
+				Reactions:  reactionsResponse(post, reactions[post.ID]),
+				CreatedAt:  post.CreatedAt,
+				UpdatedAt:  post.UpdatedAt,
+			})
+		}
+
+		if len(posts) == 0 || len(export.Posts) >= totalCount {
+			return export, nil
+		}
+		pagination.Page++
+	}
+}
+
+// writeExportArchive writes an export as a ZIP archive of profile.json and posts.json
+func writeExportArchive(w http.ResponseWriter, export *model.AccountExport) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.User},
+		{"posts.json", export.Posts},
+	}
+	for _, file := range files {
+		js, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(js)
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// auditAccountEvent records an account lifecycle event taken by the user
+func (app *Application) auditAccountEvent(r *http.Request, event string, userID int64) {
+	err := app.AuditStore.Create(r.Context(), &store.AuditEvent{
+		UserID: &userID,
+		Event:  event,
+		IP:     clientIP(r),
+	})
+	if err != nil {
+		app.Logger.Printf("Error writing audit event: %v", err)
+	}
+}
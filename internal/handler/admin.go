@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"social-api/internal/auth"
+	"social-api/internal/cache"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// AdminListUsers handles listing and searching users.
+// Supports ?search= (username or email), ?is_active= and ?role= filters.
+func (app *Application) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	// Get pagination params
+	pagination := model.GetPagination(r)
+
+	// Get filter params
+	filter := model.UserFilter{}
+	query := r.URL.Query()
+
+	if search := query.Get("search"); search != "" {
+		filter.Search = &search
+	}
+
+	if isActiveStr := query.Get("is_active"); isActiveStr != "" {
+		isActive, err := strconv.ParseBool(isActiveStr)
+		if err != nil {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "is_active", Message: "Must be true or false"},
+			})
+			return
+		}
+		filter.IsActive = &isActive
+	}
+
+	if role := query.Get("role"); role != "" {
+		filter.Role = &role
+	}
+
+	// Get users from database
+	users, totalCount, err := app.UserStore.List(r.Context(), pagination, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.AdminUserResponse, len(users))
+	for i, user := range users {
+		responses[i] = adminUserResponse(user)
+	}
+
+	// Send response with pagination
+	err = model.WriteJSON(
+		w,
+		http.StatusOK,
+		model.NewPageResponse(
+			responses,
+			pagination.Page,
+			pagination.PageSize,
+			totalCount,
+		),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// AdminGetUser handles viewing a single user
+func (app *Application) AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get user from database
+	user, err := app.UserStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(adminUserResponse(user)))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// AdminDeactivateUser handles deactivating an account. The user is signed
+// out everywhere and can no longer log in until reactivated.
+func (app *Application) AdminDeactivateUser(w http.ResponseWriter, r *http.Request) {
+	app.setUserActive(w, r, false)
+}
+
+// AdminReactivateUser handles reactivating a deactivated account
+func (app *Application) AdminReactivateUser(w http.ResponseWriter, r *http.Request) {
+	app.setUserActive(w, r, true)
+}
+
+// setUserActive activates or deactivates the user in the URL and records it in the audit log
+func (app *Application) setUserActive(w http.ResponseWriter, r *http.Request, active bool) {
+	// Get admin from context
+	admin, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Admins cannot lock themselves out
+	if !active && id == admin.ID {
+		app.badRequestResponse(w, r, errors.New("you cannot deactivate your own account"))
+		return
+	}
+
+	// Update user in database
+	err = app.UserStore.SetActive(r.Context(), id, active)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Sign a deactivated user out of every session
+	event := store.AuditUserReactivated
+	if !active {
+		event = store.AuditUserDeactivated
+
+		err = app.revokeAllSessions(r.Context(), id)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	app.invalidateUserCache(r.Context(), id)
+	app.auditAdminAction(r, event, id, admin)
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminForcePasswordReset handles forcing a user to reset their password.
+// The current password stops working, every session is signed out and the
+// user is emailed a password reset link.
+func (app *Application) AdminForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	// Get admin from context
+	admin, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get user from database
+	user, err := app.UserStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Replace the password with an unguessable one
+	password, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	err = user.Password.Set(password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	err = app.UserStore.SetPassword(r.Context(), user.ID, user.Password.Hash)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Sign the user out everywhere
+	err = app.revokeAllSessions(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Email a reset link
+	err = app.sendPasswordResetEmail(r.Context(), user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.invalidateUserCache(r.Context(), user.ID)
+	app.auditAdminAction(r, store.AuditPasswordResetForced, user.ID, admin)
+
+	// Send accepted response
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// AdminListUserPosts handles listing a user's posts
+func (app *Application) AdminListUserPosts(w http.ResponseWriter, r *http.Request) {
+	// Get admin from context
+	admin, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Check user exists
+	_, err = app.UserStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Get posts from database
+	pagination := model.GetPagination(r)
+	posts, totalCount, err := app.PostStore.List(r.Context(), admin, pagination, model.PostFilter{UserID: &id})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Get the admin's reactions
+	reactions, err := app.viewerReactions(r.Context(), admin, posts)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	postResponses := make([]model.PostResponse, len(posts))
+	for i, post := range posts {
+		postResponses[i] = app.postResponse(admin, post, reactions[post.ID])
+	}
+
+	// Send response with pagination
+	err = model.WriteJSON(
+		w,
+		http.StatusOK,
+		model.NewPageResponse(
+			postResponses,
+			pagination.Page,
+			pagination.PageSize,
+			totalCount,
+		),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// AdminListUserSessions handles listing a user's active sessions
+func (app *Application) AdminListUserSessions(w http.ResponseWriter, r *http.Request) {
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Check user exists
+	_, err = app.UserStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Get sessions from database
+	sessions, err := app.SessionStore.ListActiveByUser(r.Context(), id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = model.SessionResponse{
+			ID:         session.ID,
+			Kind:       session.Kind,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			CreatedAt:  session.CreatedAt,
+		}
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(responses))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// invalidateUserCache removes a user from the cache after an admin change
+func (app *Application) invalidateUserCache(ctx context.Context, userID int64) {
+	if app.Cache == nil {
+		return
+	}
+
+	err := app.Cache.Delete(ctx, cache.UserKey(userID))
+	if err != nil {
+		app.Logger.Printf("Error deleting user from cache: %v", err)
+	}
+}
+
+// auditAdminAction records an admin action taken on a user
+func (app *Application) auditAdminAction(r *http.Request, event string, userID int64, admin *store.User) {
+	err := app.AuditStore.Create(r.Context(), &store.AuditEvent{
+		UserID: &userID,
+		Event:  event,
+		IP:     clientIP(r),
+		Details: map[string]interface{}{
+			"admin_id": admin.ID,
+		},
+	})
+	if err != nil {
+		app.Logger.Printf("Error writing audit event: %v", err)
+	}
+
+	app.Logger.Printf("Admin %d: %s for user %d", admin.ID, event, userID)
+}
+
+// adminUserResponse converts a user to its admin response representation
+func adminUserResponse(user *store.User) model.AdminUserResponse {
+	return model.AdminUserResponse{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		IsActive:        user.IsActive,
+		Roles:           user.Roles,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+	}
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// CreateAPIKey handles the API key creation endpoint
+func (app *Application) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// API keys cannot be used to mint further API keys
+	if identity, ok := auth.GetIdentityFromContext(r.Context()); ok && identity.Scheme == auth.SchemeAPIKey {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.APIKeyInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "expires_at", Message: "Must be in the future"},
+		})
+		return
+	}
+
+	// Generate key
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Create API key in database
+	apiKey := &store.APIKey{
+		UserID:    user.ID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		ExpiresAt: input.ExpiresAt,
+	}
+	err = app.APIKeyStore.Create(r.Context(), apiKey)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to response
+	response := model.APIKeyCreatedResponse{
+		APIKeyResponse: model.APIKeyResponse{
+			ID:         apiKey.ID,
+			Name:       apiKey.Name,
+			Prefix:     apiKey.Prefix,
+			ExpiresAt:  apiKey.ExpiresAt,
+			LastUsedAt: apiKey.LastUsedAt,
+			CreatedAt:  apiKey.CreatedAt,
+		},
+		Key: key,
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusCreated, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// ListAPIKeys handles the list API keys endpoint
+func (app *Application) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get API keys from database
+	apiKeys, err := app.APIKeyStore.ListByUser(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.APIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		responses[i] = model.APIKeyResponse{
+			ID:         apiKey.ID,
+			Name:       apiKey.Name,
+			Prefix:     apiKey.Prefix,
+			ExpiresAt:  apiKey.ExpiresAt,
+			LastUsedAt: apiKey.LastUsedAt,
+			CreatedAt:  apiKey.CreatedAt,
+		}
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(responses))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteAPIKey handles the API key revocation endpoint
+func (app *Application) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract API key ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Delete API key from database
+	err = app.APIKeyStore.Delete(r.Context(), id, user.ID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
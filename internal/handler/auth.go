@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// startTokenSession records a new token login session for a user and issues
+// its first access token and refresh token pair
+func (app *Application) startTokenSession(r *http.Request, user *store.User) (*model.TokenResponse, error) {
+	// Record the session
+	session, err := app.createSession(r, user, store.SessionKindToken, "", app.Config.Auth.RefreshTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.issueTokens(r.Context(), user, session.ID, "")
+}
+
+// issueTokens creates a new access token and refresh token pair for a user's
+// session. An empty familyID starts a new refresh token family.
+func (app *Application) issueTokens(ctx context.Context, user *store.User, sessionID int64, familyID string) (*model.TokenResponse, error) {
+	// Generate access token
+	token, err := app.Authenticator.GenerateToken(user.ID, app.Config.Auth.TokenExpiry, auth.WithSessionID(sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate refresh token
+	refreshToken, refreshHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Store refresh token
+	record := &store.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(app.Config.Auth.RefreshTokenExpiry),
+	}
+	err = app.RefreshTokenStore.Create(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create response
+	response := &model.TokenResponse{
+		Token:                 token,
+		User:                  app.userResponse(user, user),
+		ExpiresAt:             time.Now().Add(app.Config.Auth.TokenExpiry),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: record.ExpiresAt,
+	}
+
+	return response, nil
+}
+
+// RefreshToken handles the refresh token endpoint.
+// Every successful refresh rotates the refresh token; presenting a token that
+// has already been rotated revokes the whole token family.
+func (app *Application) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var input model.RefreshTokenInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Look up refresh token
+	current, err := app.RefreshTokenStore.GetByHash(r.Context(), auth.HashToken(input.RefreshToken))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidRefreshTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Reject revoked and expired tokens, and tokens issued to OAuth2 clients,
+	// which are only refreshed through the OAuth2 token endpoint
+	if current.RevokedAt != nil || current.IsExpired() || current.ClientID != 0 {
+		app.invalidRefreshTokenResponse(w, r)
+		return
+	}
+
+	// A token that was already rotated is being replayed
+	if current.UsedAt != nil {
+		app.refreshTokenReuseResponse(w, r, current)
+		return
+	}
+
+	// Mark token as used; ErrNotFound means a concurrent request rotated it first
+	err = app.RefreshTokenStore.MarkUsed(r.Context(), current.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.refreshTokenReuseResponse(w, r, current)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Get user
+	user, err := app.UserStore.GetByID(r.Context(), current.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidRefreshTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Keep the session alive as long as its refresh tokens
+	if current.SessionID != 0 {
+		err = app.SessionStore.Extend(r.Context(), current.SessionID, time.Now().Add(app.Config.Auth.RefreshTokenExpiry))
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	// Issue a new token pair in the same family
+	response, err := app.issueTokens(r.Context(), user, current.SessionID, current.FamilyID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// refreshTokenReuseResponse revokes the family and session of a replayed
+// refresh token and rejects the request
+func (app *Application) refreshTokenReuseResponse(w http.ResponseWriter, r *http.Request, token *store.RefreshToken) {
+	app.Logger.Printf("Refresh token reuse detected for user %d, revoking family %s", token.UserID, token.FamilyID)
+
+	err := app.RefreshTokenStore.RevokeFamily(r.Context(), token.FamilyID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// End the session the family belongs to, which also rejects its access tokens
+	if token.SessionID != 0 {
+		err = app.endSession(r.Context(), token.SessionID, token.UserID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	app.invalidRefreshTokenResponse(w, r)
+}
+
+// Logout handles the logout endpoint.
+// It ends the session behind the request, revoking its access and refresh
+// tokens, and clears the cookies of browser sessions. Tokens issued without
+// a session are revoked individually: the access token used for the request
+// and, if one is supplied, the refresh token family issued alongside it.
+func (app *Application) Logout(w http.ResponseWriter, r *http.Request) {
+	// Get user and identity from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+	identity, ok := auth.GetIdentityFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// End the session, revoking its access and refresh tokens
+	if identity.SessionID != 0 {
+		err := app.endSession(r.Context(), identity.SessionID, user.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	// Browser sessions only need their cookies cleared
+	if identity.Scheme == auth.SchemeSession {
+		app.clearSessionCookies(w)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	claims, ok := auth.GetClaimsFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Parse optional request body
+	var input model.LogoutInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Revoke the current access token
+	err = app.Revocations.Revoke(r.Context(), claims)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Revoke the refresh token family if it belongs to this user
+	if input.RefreshToken != "" {
+		refreshToken, err := app.RefreshTokenStore.GetByHash(r.Context(), auth.HashToken(input.RefreshToken))
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if err == nil && refreshToken.UserID == user.ID {
+			err = app.RefreshTokenStore.RevokeFamily(r.Context(), refreshToken.FamilyID)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+		}
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll handles the logout-all endpoint.
+// It revokes every access token, refresh token and browser session of the current user.
+func (app *Application) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Revoke all tokens
+	err := app.revokeAllSessions(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllSessions revokes every access token, refresh token and browser
+// session issued to a user
+func (app *Application) revokeAllSessions(ctx context.Context, userID int64) error {
+	// Revoke all access tokens
+	err := app.Revocations.RevokeAll(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Revoke all refresh tokens
+	err = app.RefreshTokenStore.RevokeAllForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Revoke all browser sessions
+	return app.SessionStore.RevokeAllForUser(ctx, userID)
+}
+
+// JWKS handles the JSON Web Key Set endpoint.
+// It publishes the public keys that downstream services can use to verify
+// access tokens without holding any signing material.
+func (app *Application) JWKS(w http.ResponseWriter, r *http.Request) {
+	// Allow verifiers to cache the key set for a short while
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	// Send the key set without the standard response envelope
+	err := model.WriteJSON(w, http.StatusOK, app.Keys.JWKS())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
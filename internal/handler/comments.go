@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// CreateComment handles commenting on a post or replying to a comment
+func (app *Application) CreateComment(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract post ID from URL
+	postID, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Parse request body
+	var input model.CommentInput
+	err = model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Check post exists
+	_, err = app.PostStore.GetByID(r.Context(), user, postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Replies must answer a comment on the same post
+	if input.ParentID != nil {
+		parent, err := app.CommentStore.GetByID(r.Context(), *input.ParentID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if err != nil || parent.PostID != postID {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "parent_id", Message: "Must be a comment on this post"},
+			})
+			return
+		}
+	}
+
+	// Create comment in database
+	comment := &store.Comment{
+		PostID:   postID,
+		ParentID: input.ParentID,
+		UserID:   user.ID,
+		Content:  input.Content,
+		User:     user,
+	}
+	err = app.CommentStore.Create(r.Context(), comment)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusCreated, model.NewResponse(app.commentResponse(user, comment)))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// ListComments handles listing a post's comments.
+// Top-level comments are paginated, each with its replies down to ?depth=
+// levels. Supports ?parent_id= to page through the replies to one comment
+// instead, and ?view=flat to list the comments in thread order without nesting.
+func (app *Application) ListComments(w http.ResponseWriter, r *http.Request) {
+	// Get viewer from context
+	viewer, _ := auth.GetUserFromContext(r.Context())
+
+	// Extract post ID from URL
+	postID, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get pagination params, listing comments oldest first by default
+	pagination := model.GetPagination(r)
+	query := r.URL.Query()
+	if query.Get("sort") == "" {
+		pagination.Sort = "asc"
+	}
+
+	// Get thread params
+	view := query.Get("view")
+	if view == "" {
+		view = "tree"
+	}
+	if view != "tree" && view != "flat" {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "view", Message: "Must be tree or flat"},
+		})
+		return
+	}
+
+	depth := model.DefaultCommentDepth
+	if depthStr := query.Get("depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 0 || depth > model.MaxCommentDepth {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "depth", Message: "Must be a number from 0 to " + strconv.Itoa(model.MaxCommentDepth)},
+			})
+			return
+		}
+	}
+
+	var parentID *int64
+	if parentIDStr := query.Get("parent_id"); parentIDStr != "" {
+		id, err := strconv.ParseInt(parentIDStr, 10, 64)
+		if err != nil || id < 1 {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "parent_id", Message: "Must be a comment ID"},
+			})
+			return
+		}
+		parentID = &id
+	}
+
+	// Check post exists
+	_, err = app.PostStore.GetByID(r.Context(), viewer, postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Get comments from database
+	comments, totalCount, err := app.CommentStore.ListThreads(r.Context(), postID, parentID, depth, pagination)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.CommentResponse, len(comments))
+	for i, comment := range comments {
+		responses[i] = app.commentResponse(viewer, comment)
+	}
+	if view == "tree" {
+		responses = commentTree(responses)
+	}
+
+	// Send response with pagination
+	err = model.WriteJSON(
+		w,
+		http.StatusOK,
+		model.NewPageResponse(
+			responses,
+			pagination.Page,
+			pagination.PageSize,
+			totalCount,
+		),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateComment handles the update comment endpoint
+func (app *Application) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract comment ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get comment from database
+	comment, err := app.CommentStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Check if user is the comment author or may edit any comment
+	if comment.UserID != user.ID && !user.HasPermission(store.PermCommentsUpdateAny) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.CommentUpdateInput
+	err = model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Update comment in database
+	comment.Content = input.Content
+	err = app.CommentStore.Update(r.Context(), comment)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(app.commentResponse(user, comment)))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteComment handles the delete comment endpoint. Replies to the comment
+// are deleted with it.
+func (app *Application) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract comment ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get comment from database
+	comment, err := app.CommentStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Check if user is the comment author or may remove any comment
+	if comment.UserID != user.ID && !user.HasPermission(store.PermCommentsDeleteAny) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Delete comment from database
+	err = app.CommentStore.Delete(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// commentResponse converts a comment to its response representation, with
+// the author projected for the viewer
+func (app *Application) commentResponse(viewer *store.User, comment *store.Comment) model.CommentResponse {
+	return model.CommentResponse{
+		ID:         comment.ID,
+		PostID:     comment.PostID,
+		ParentID:   comment.ParentID,
+		Content:    comment.Content,
+		User:       app.userResponse(viewer, comment.User),
+		Depth:      comment.Depth,
+		ReplyCount: comment.ReplyCount,
+		CreatedAt:  comment.CreatedAt,
+		UpdatedAt:  comment.UpdatedAt,
+	}
+}
+
+// commentTree nests comments listed in thread order under their parents and
+// returns the comments at the top of the listed threads
+func commentTree(comments []model.CommentResponse) []model.CommentResponse {
+	// Index the replies to each comment, keeping their order
+	replies := make(map[int64][]int)
+	var roots []int
+	for i, comment := range comments {
+		if comment.Depth == 0 {
+			roots = append(roots, i)
+		} else {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], i)
+		}
+	}
+
+	var build func(i int) model.CommentResponse
+	build = func(i int) model.CommentResponse {
+		comment := comments[i]
+		for _, j := range replies[comment.ID] {
+			comment.Replies = append(comment.Replies, build(j))
+		}
+		return comment
+	}
+
+	tree := make([]model.CommentResponse, len(roots))
+	for i, root := range roots {
+		tree[i] = build(root)
+	}
+
+	return tree
+}
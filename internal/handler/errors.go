@@ -2,8 +2,12 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
+	"social-api/internal/auth"
 	"social-api/internal/model"
 	"social-api/internal/store"
 )
@@ -60,11 +64,73 @@ func (app *Application) unauthorizedResponse(w http.ResponseWriter, r *http.Requ
 	app.respondError(w, http.StatusUnauthorized, "You must be authenticated to access this resource")
 }
 
+// invalidRefreshTokenResponse sends a 401 Unauthorized response for a bad refresh token
+func (app *Application) invalidRefreshTokenResponse(w http.ResponseWriter, r *http.Request) {
+	app.respondError(w, http.StatusUnauthorized, "invalid or expired refresh token")
+}
+
+// invalidMFATokenResponse sends a 401 Unauthorized response for a bad pending two-factor token
+func (app *Application) invalidMFATokenResponse(w http.ResponseWriter, r *http.Request) {
+	app.respondError(w, http.StatusUnauthorized, "invalid or expired two-factor token")
+}
+
+// invalidMFACodeResponse sends a 422 Unprocessable Entity response for a wrong two-factor code
+func (app *Application) invalidMFACodeResponse(w http.ResponseWriter, r *http.Request) {
+	app.validationErrorResponse(w, r, []ValidationError{
+		{Field: "code", Message: "Invalid two-factor code"},
+	})
+}
+
+// invalidOIDCResponse sends a 401 Unauthorized response for a sign-in with an
+// identity provider that could not be verified
+func (app *Application) invalidOIDCResponse(w http.ResponseWriter, r *http.Request) {
+	app.respondError(w, http.StatusUnauthorized, "sign-in with the identity provider could not be verified")
+}
+
+// identityProviderErrorResponse sends a 502 Bad Gateway response when an identity provider fails
+func (app *Application) identityProviderErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.Logger.Printf("IDENTITY PROVIDER ERROR: %s", err.Error())
+	app.respondError(w, http.StatusBadGateway, "the identity provider could not be reached")
+}
+
+// oauthErrorResponse sends an OAuth2 token endpoint error without the
+// standard error envelope, as clients expect (RFC 6749 section 5.2)
+func (app *Application) oauthErrorResponse(w http.ResponseWriter, r *http.Request, status int, code, description string) {
+	app.Logger.Printf("OAUTH ERROR: %s: %s (status: %d)", code, description, status)
+	model.WriteJSON(w, status, model.OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// invalidVerificationTokenResponse sends a 400 Bad Request response for a bad verification token
+func (app *Application) invalidVerificationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	app.respondError(w, http.StatusBadRequest, "invalid, expired or already used verification token")
+}
+
+// invalidResetTokenResponse sends a 400 Bad Request response for a bad password reset token
+func (app *Application) invalidResetTokenResponse(w http.ResponseWriter, r *http.Request) {
+	app.respondError(w, http.StatusBadRequest, "invalid, expired or already used password reset token")
+}
+
 // forbiddenResponse sends a 403 Forbidden response
 func (app *Application) forbiddenResponse(w http.ResponseWriter, r *http.Request) {
 	app.respondError(w, http.StatusForbidden, "You don't have permission to access this resource")
 }
 
+// lockedOutResponse sends a 423 Locked response for a locked account, or a
+// 429 Too Many Requests response for a blocked IP address
+func (app *Application) lockedOutResponse(w http.ResponseWriter, r *http.Request, lockout *auth.Lockout) {
+	retryAfter := int(math.Ceil(lockout.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	if lockout.Scope == auth.LockoutScopeIP {
+		app.respondError(w, http.StatusTooManyRequests, fmt.Sprintf("too many failed login attempts, try again in %d seconds", retryAfter))
+		return
+	}
+	app.respondError(w, http.StatusLocked, fmt.Sprintf("account temporarily locked after too many failed login attempts, try again in %d seconds", retryAfter))
+}
+
 // conflictResponse sends a 409 Conflict response
 func (app *Application) conflictResponse(w http.ResponseWriter, r *http.Request, err error) {
 	app.respondError(w, http.StatusConflict, err.Error())
@@ -79,6 +145,8 @@ func (app *Application) handleError(w http.ResponseWriter, r *http.Request, err
 		app.conflictResponse(w, r, err)
 	case errors.Is(err, store.ErrDuplicateUsername):
 		app.conflictResponse(w, r, err)
+	case errors.Is(err, store.ErrMFAEnabled):
+		app.conflictResponse(w, r, err)
 	default:
 		app.serverErrorResponse(w, r, err)
 	}
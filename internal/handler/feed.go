@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// GetFeed handles the home timeline endpoint: the posts of the current user
+// and the users they follow, newest first. Pages are fetched with the
+// next_cursor of the previous page in ?cursor=.
+func (app *Application) GetFeed(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get pagination params
+	pagination := model.GetPagination(r)
+
+	var before int64
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		var err error
+		before, err = model.DecodeCursor(cursor)
+		if err != nil {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "cursor", Message: "Must be the next_cursor of a previous page"},
+			})
+			return
+		}
+	}
+
+	// Get posts from the timeline
+	posts, err := app.Feed.Page(r.Context(), user, before, pagination.PageSize)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	reactions, err := app.viewerReactions(r.Context(), user, posts)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.PostResponse, len(posts))
+	for i, post := range posts {
+		responses[i] = app.postResponse(user, post, reactions[post.ID])
+	}
+
+	// A full page may be followed by more posts
+	var nextCursor string
+	if len(posts) == pagination.PageSize {
+		nextCursor = model.EncodeCursor(posts[len(posts)-1].ID)
+	}
+
+	// Send response with cursor
+	err = model.WriteJSON(w, http.StatusOK, model.NewCursorResponse(responses, pagination.PageSize, nextCursor))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// publishPost adds a new post to timelines in the background, since an
+// author may have many followers
+func (app *Application) publishPost(post *store.Post) {
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		err := app.Feed.Publish(ctx, post)
+		if err != nil {
+			app.Logger.Printf("Error publishing post %d to timelines: %v", post.ID, err)
+		}
+	}()
+}
+
+// invalidateFeeds discards the timelines of users whose follows changed
+func (app *Application) invalidateFeeds(ctx context.Context, userIDs ...int64) {
+	for _, userID := range userIDs {
+		err := app.Feed.Invalidate(ctx, userID)
+		if err != nil {
+			app.Logger.Printf("Error invalidating timeline of user %d: %v", userID, err)
+		}
+	}
+}
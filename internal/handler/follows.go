@@ -0,0 +1,333 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// FollowUser handles following a user. Following a private account creates a
+// pending follow request instead. Following a user again has no effect.
+func (app *Application) FollowUser(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if id == user.ID {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "id", Message: "Cannot follow yourself"},
+		})
+		return
+	}
+
+	// Get followed user from database; deactivated accounts cannot be followed
+	followee, err := app.UserStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if !followee.IsActive {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Create follow in database, pending approval for private accounts
+	follow := &store.Follow{
+		FollowerID: user.ID,
+		FolloweeID: followee.ID,
+		Status:     store.FollowAccepted,
+	}
+	if followee.IsPrivate {
+		follow.Status = store.FollowPending
+	}
+
+	err = app.FollowStore.Create(r.Context(), follow)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if follow.Status == store.FollowAccepted {
+		app.invalidateFeeds(r.Context(), user.ID)
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(model.FollowResponse{
+		User:      app.userResponse(user, followee),
+		Status:    follow.Status,
+		CreatedAt: follow.CreatedAt,
+	}))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// UnfollowUser handles unfollowing a user or withdrawing a follow request.
+// Unfollowing a user that is not followed has no effect.
+func (app *Application) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Delete follow from database
+	err = app.FollowStore.Delete(r.Context(), user.ID, id)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.invalidateFeeds(r.Context(), user.ID)
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListFollowers handles listing a user's followers, newest first. The
+// followers of a private account are only listed to its accepted followers.
+func (app *Application) ListFollowers(w http.ResponseWriter, r *http.Request) {
+	app.listFollows(w, r, true)
+}
+
+// ListFollowing handles listing the users a user follows, newest first. The
+// follows of a private account are only listed to its accepted followers.
+func (app *Application) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	app.listFollows(w, r, false)
+}
+
+// listFollows lists the followers of the user in the URL, or the users they follow
+func (app *Application) listFollows(w http.ResponseWriter, r *http.Request, followers bool) {
+	// Get viewer from context
+	viewer, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract user ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get user from database; deactivated accounts have no public profile
+	user, err := app.UserStore.GetByID(r.Context(), id)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if !user.IsActive && user.ID != viewer.ID {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Check the viewer may see the user's follows
+	allowed, err := app.canSeeFollows(r.Context(), viewer, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Get pagination params
+	pagination := model.GetPagination(r)
+
+	// Get follows from database
+	var follows []*store.Follow
+	var totalCount int
+	if followers {
+		follows, totalCount, err = app.FollowStore.ListFollowers(r.Context(), user.ID, store.FollowAccepted, pagination)
+	} else {
+		follows, totalCount, err = app.FollowStore.ListFollowing(r.Context(), user.ID, pagination)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeFollows(w, r, viewer, follows, pagination, totalCount)
+}
+
+// ListFollowRequests handles listing the pending follow requests to the
+// current user, newest first
+func (app *Application) ListFollowRequests(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get pagination params
+	pagination := model.GetPagination(r)
+
+	// Get follow requests from database
+	follows, totalCount, err := app.FollowStore.ListFollowers(r.Context(), user.ID, store.FollowPending, pagination)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeFollows(w, r, user, follows, pagination, totalCount)
+}
+
+// AcceptFollowRequest handles accepting a pending follow request from the
+// user in the URL
+func (app *Application) AcceptFollowRequest(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract follower ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Accept follow request in database
+	err = app.FollowStore.Accept(r.Context(), id, user.ID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	app.invalidateFeeds(r.Context(), id)
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RejectFollowRequest handles rejecting a pending follow request from the
+// user in the URL
+func (app *Application) RejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract follower ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get follow request from database; accepted follows are not requests
+	follow, err := app.FollowStore.Get(r.Context(), id, user.ID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if follow.Status != store.FollowPending {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Delete follow request from database
+	err = app.FollowStore.Delete(r.Context(), id, user.ID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeFollows sends a page of follows, with the users projected for the viewer
+func (app *Application) writeFollows(w http.ResponseWriter, r *http.Request, viewer *store.User, follows []*store.Follow, pagination model.Pagination, totalCount int) {
+	// Convert to responses
+	responses := make([]model.FollowResponse, len(follows))
+	for i, follow := range follows {
+		responses[i] = model.FollowResponse{
+			User:      app.userResponse(viewer, follow.User),
+			Status:    follow.Status,
+			CreatedAt: follow.CreatedAt,
+		}
+	}
+
+	// Send response with pagination
+	err := model.WriteJSON(
+		w,
+		http.StatusOK,
+		model.NewPageResponse(
+			responses,
+			pagination.Page,
+			pagination.PageSize,
+			totalCount,
+		),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// canSeeFollows reports whether the viewer may see who a user follows and is
+// followed by. Private accounts only show them to their accepted followers.
+func (app *Application) canSeeFollows(ctx context.Context, viewer, user *store.User) (bool, error) {
+	if !user.IsPrivate || relation(viewer, user) != model.RelationOther {
+		return true, nil
+	}
+
+	follow, err := app.FollowStore.Get(ctx, viewer.ID, user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return follow.Status == store.FollowAccepted, nil
+}
+
+// profileResponse converts a user to its response representation for the
+// viewer, with the user's follower counts
+func (app *Application) profileResponse(ctx context.Context, viewer, user *store.User) (model.UserResponse, error) {
+	response := app.userResponse(viewer, user)
+
+	followers, following, err := app.FollowStore.Counts(ctx, user.ID)
+	if err != nil {
+		return response, err
+	}
+	response.FollowerCount = &followers
+	response.FollowingCount = &following
+
+	return response, nil
+}
@@ -3,9 +3,11 @@ package handler
 import (
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
@@ -13,42 +15,111 @@ import (
 	"social-api/internal/auth"
 	"social-api/internal/cache"
 	"social-api/internal/config"
+	"social-api/internal/feed"
+	"social-api/internal/mailer"
+	"social-api/internal/model"
 	"social-api/internal/store"
 )
 
 // Application contains the application handler dependencies
 type Application struct {
-	Config        config.Config
-	Logger        *log.Logger
-	Authenticator *auth.JWTAuthenticator
-	Cache         cache.Cache
-	UserStore     store.UserStore
-	PostStore     store.PostStore
-	Validator     *validator.Validate
+	Config             config.Config
+	Logger             *log.Logger
+	Authenticator      auth.Authenticator
+	Keys               *auth.KeySet
+	Cache              cache.Cache
+	UserStore          store.UserStore
+	PostStore          store.PostStore
+	RefreshTokenStore  store.RefreshTokenStore
+	APIKeyStore        store.APIKeyStore
+	PasswordResetStore store.PasswordResetStore
+	AuditStore         store.AuditStore
+	MFAStore           store.MFAStore
+	SessionStore       store.SessionStore
+	UserIdentityStore  store.UserIdentityStore
+	OAuthStore         store.OAuthStore
+	CommentStore       store.CommentStore
+	ReactionStore      store.ReactionStore
+	FollowStore        store.FollowStore
+	Revocations        *auth.RevocationList
+	Signer             *auth.TokenSigner
+	LoginGuard         *auth.LoginGuard
+	OIDCProviders      map[string]*auth.OIDCProvider
+	Projection         *model.UserProjection
+	Mailer             mailer.Mailer
+	Feed               feed.Feed
+	Validator          *validator.Validate
+
+	// background tracks work that outlives its request, such as publishing
+	// posts to timelines
+	background sync.WaitGroup
 }
 
 // NewApplication creates a new application handler
 func NewApplication(
 	cfg config.Config,
 	logger *log.Logger,
-	authenticator *auth.JWTAuthenticator,
+	authenticator auth.Authenticator,
+	keys *auth.KeySet,
 	cache cache.Cache,
 	userStore store.UserStore,
 	postStore store.PostStore,
+	refreshTokenStore store.RefreshTokenStore,
+	apiKeyStore store.APIKeyStore,
+	passwordResetStore store.PasswordResetStore,
+	auditStore store.AuditStore,
+	mfaStore store.MFAStore,
+	sessionStore store.SessionStore,
+	userIdentityStore store.UserIdentityStore,
+	oauthStore store.OAuthStore,
+	commentStore store.CommentStore,
+	reactionStore store.ReactionStore,
+	followStore store.FollowStore,
+	revocations *auth.RevocationList,
+	signer *auth.TokenSigner,
+	loginGuard *auth.LoginGuard,
+	oidcProviders map[string]*auth.OIDCProvider,
+	projection *model.UserProjection,
+	mailer mailer.Mailer,
+	feed feed.Feed,
 ) *Application {
 	validate := validator.New()
 
 	return &Application{
-		Config:        cfg,
-		Logger:        logger,
-		Authenticator: authenticator,
-		Cache:         cache,
-		UserStore:     userStore,
-		PostStore:     postStore,
-		Validator:     validate,
+		Config:             cfg,
+		Logger:             logger,
+		Authenticator:      authenticator,
+		Keys:               keys,
+		Cache:              cache,
+		UserStore:          userStore,
+		PostStore:          postStore,
+		RefreshTokenStore:  refreshTokenStore,
+		APIKeyStore:        apiKeyStore,
+		PasswordResetStore: passwordResetStore,
+		AuditStore:         auditStore,
+		MFAStore:           mfaStore,
+		SessionStore:       sessionStore,
+		UserIdentityStore:  userIdentityStore,
+		OAuthStore:         oauthStore,
+		CommentStore:       commentStore,
+		ReactionStore:      reactionStore,
+		FollowStore:        followStore,
+		Revocations:        revocations,
+		Signer:             signer,
+		LoginGuard:         loginGuard,
+		OIDCProviders:      oidcProviders,
+		Projection:         projection,
+		Mailer:             mailer,
+		Feed:               feed,
+		Validator:          validate,
 	}
 }
 
+// Wait blocks until background work started by handlers has finished
+func (app *Application) Wait() {
+	app.background.Wait()
+}
+
 // GetIDParam extracts and parses an ID URL parameter
 func (app *Application) GetIDParam(r *http.Request) (int64, error) {
 	idParam := chi.URLParam(r, "id")
@@ -59,6 +130,16 @@ func (app *Application) GetIDParam(r *http.Request) (int64, error) {
 	return id, nil
 }
 
+// clientIP returns the client IP address of a request, without the port.
+// RemoteAddr has already been rewritten by the RealIP middleware.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // ValidateRequest validates a request body
 func (app *Application) ValidateRequest(v interface{}) error {
 	return app.Validator.Struct(v)
@@ -0,0 +1,435 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// mfaChallengeResponse issues a short-lived pending token after a correct
+// password, which must be exchanged together with a second factor at
+// POST /auth/mfa/verify
+func (app *Application) mfaChallengeResponse(w http.ResponseWriter, r *http.Request, user *store.User) {
+	// Generate pending token
+	token, err := app.Authenticator.GenerateToken(
+		user.ID,
+		app.Config.Auth.MFATokenExpiry,
+		auth.WithPurpose(auth.PurposeMFAPending),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := model.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   time.Now().Add(app.Config.Auth.MFATokenExpiry),
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// VerifyMFA handles the second step of a two-factor login.
+// It exchanges a pending token and a TOTP or recovery code for a full token pair.
+func (app *Application) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var input model.MFAVerifyInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Validate pending token
+	claims, err := app.Authenticator.ValidateToken(input.MFAToken)
+	if err != nil || claims.Purpose != auth.PurposeMFAPending {
+		app.invalidMFATokenResponse(w, r)
+		return
+	}
+
+	// Pending tokens are single use
+	revoked, err := app.Revocations.IsRevoked(r.Context(), claims)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if revoked {
+		app.invalidMFATokenResponse(w, r)
+		return
+	}
+
+	// Get user
+	user, err := app.UserStore.GetByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidMFATokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Code guesses count towards the same lockout as password guesses
+	ip := clientIP(r)
+	lockout, err := app.LoginGuard.Check(r.Context(), user.Email, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if lockout != nil {
+		app.lockedOutResponse(w, r, lockout)
+		return
+	}
+
+	// Get enrollment
+	mfa, err := app.MFAStore.Get(r.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidMFATokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !mfa.IsEnabled() {
+		app.invalidMFATokenResponse(w, r)
+		return
+	}
+
+	// Check second factor
+	ok, err := app.checkMFACode(r.Context(), mfa, input.Code)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !ok {
+		app.loginFailedResponse(w, r, user.Email, ip, user)
+		return
+	}
+
+	// Clear the account's failure counter
+	err = app.LoginGuard.Succeed(r.Context(), user.Email)
+	if err != nil {
+		app.Logger.Printf("Error resetting login failures: %v", err)
+	}
+
+	// Consume the pending token
+	err = app.Revocations.Revoke(r.Context(), claims)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.completeLogin(w, r, user, input.Session)
+}
+
+// checkMFACode checks a TOTP code, or failing that a recovery code, against
+// an enabled enrollment. Accepted codes are consumed so they cannot be replayed.
+func (app *Application) checkMFACode(ctx context.Context, mfa *store.UserMFA, code string) (bool, error) {
+	// Try TOTP code
+	if step, ok := auth.ValidateTOTP(mfa.Secret, code, time.Now()); ok {
+		err := app.MFAStore.UseStep(ctx, mfa.UserID, step)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	// Try recovery code
+	err := app.MFAStore.UseRecoveryCode(ctx, mfa.UserID, auth.HashRecoveryCode(code))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// EnrollTOTP handles starting TOTP enrollment for the current user.
+// The returned secret is not active until confirmed with a first code.
+func (app *Application) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Generate secret
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Store pending enrollment
+	err = app.MFAStore.SetPendingSecret(r.Context(), user.ID, secret)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	response := model.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(app.Config.Auth.MFAIssuer, user.Email, secret),
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// ConfirmTOTP handles confirming a pending TOTP enrollment with a first code.
+// It enables two-factor authentication and returns the user's recovery codes.
+func (app *Application) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.MFACodeInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Get pending enrollment
+	mfa, err := app.MFAStore.Get(r.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.badRequestResponse(w, r, errors.New("no pending two-factor enrollment"))
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if mfa.IsEnabled() {
+		app.conflictResponse(w, r, store.ErrMFAEnabled)
+		return
+	}
+
+	// Check code
+	step, ok := auth.ValidateTOTP(mfa.Secret, input.Code, time.Now())
+	if !ok {
+		app.invalidMFACodeResponse(w, r)
+		return
+	}
+
+	// Generate recovery codes
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Enable two-factor authentication; ErrNotFound means a concurrent request enabled it first
+	err = app.MFAStore.Enable(r.Context(), user.ID, step, hashes)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.conflictResponse(w, r, store.ErrMFAEnabled)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(model.RecoveryCodesResponse{RecoveryCodes: codes}))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// DisableTOTP handles turning off two-factor authentication for the current user.
+// It requires both the password and a current TOTP or recovery code.
+func (app *Application) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.MFADisableInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Check password
+	match, _, err := user.Password.Matches(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !match {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "password", Message: "Password is incorrect"},
+		})
+		return
+	}
+
+	// Check second factor
+	mfa, ok := app.enabledMFA(w, r, user)
+	if !ok {
+		return
+	}
+
+	ok, err = app.checkMFACode(r.Context(), mfa, input.Code)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !ok {
+		app.invalidMFACodeResponse(w, r)
+		return
+	}
+
+	// Remove enrollment and recovery codes
+	err = app.MFAStore.Disable(r.Context(), user.ID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles replacing the current user's recovery codes.
+// Any unused codes from the previous set stop working.
+func (app *Application) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.MFACodeInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Check second factor
+	mfa, ok := app.enabledMFA(w, r, user)
+	if !ok {
+		return
+	}
+
+	ok, err = app.checkMFACode(r.Context(), mfa, input.Code)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !ok {
+		app.invalidMFACodeResponse(w, r)
+		return
+	}
+
+	// Generate and store new recovery codes
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.MFAStore.ReplaceRecoveryCodes(r.Context(), user.ID, hashes)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(model.RecoveryCodesResponse{RecoveryCodes: codes}))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// enabledMFA loads the user's enrollment, responding with 400 Bad Request
+// if two-factor authentication is not enabled
+func (app *Application) enabledMFA(w http.ResponseWriter, r *http.Request, user *store.User) (*store.UserMFA, bool) {
+	mfa, err := app.MFAStore.Get(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+
+	if err != nil || !mfa.IsEnabled() {
+		app.badRequestResponse(w, r, errors.New("two-factor authentication is not enabled"))
+		return nil, false
+	}
+
+	return mfa, true
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"social-api/internal/auth"
+	"social-api/internal/config"
+	"social-api/internal/store"
+)
+
+// fakeMFAStore serves enrollments by user ID; other methods are not used
+type fakeMFAStore struct {
+	store.MFAStore
+	enrollments map[int64]*store.UserMFA
+}
+
+func (s *fakeMFAStore) Get(ctx context.Context, userID int64) (*store.UserMFA, error) {
+	mfa, ok := s.enrollments[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return mfa, nil
+}
+
+func TestCreateTokenKeepsMFAFailures(t *testing.T) {
+	const (
+		email    = "alice@example.com"
+		password = "correct horse battery staple"
+	)
+
+	user := &store.User{ID: 1, Username: "alice", Email: email, IsActive: true}
+	if err := user.Password.Set(password); err != nil {
+		t.Fatalf("setting password: %v", err)
+	}
+	enabledAt := time.Now()
+
+	attempts := auth.NewMemoryAttemptStore()
+	policy := auth.LockoutPolicy{Threshold: 10, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+
+	app := &Application{
+		Config:        config.Config{Auth: config.AuthConfig{MFATokenExpiry: 5 * time.Minute}},
+		Authenticator: auth.NewJWTAuthenticator(auth.NewHMACKeySet("test-secret"), "test", "test"),
+		UserStore:     &fakeUserStore{users: []*store.User{user}},
+		MFAStore:      &fakeMFAStore{enrollments: map[int64]*store.UserMFA{user.ID: {UserID: user.ID, EnabledAt: &enabledAt}}},
+		LoginGuard:    auth.NewLoginGuard(attempts, policy, policy),
+		Validator:     validator.New(),
+	}
+
+	// Record failed second-factor guesses, as VerifyMFA does
+	for i := 0; i < 3; i++ {
+		if _, err := app.LoginGuard.Fail(context.Background(), email, "192.0.2.1"); err != nil {
+			t.Fatalf("recording failure: %v", err)
+		}
+	}
+
+	// Log in with the password again from another IP
+	body := `{"email":"` + email + `","password":"` + password + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/token", strings.NewReader(body))
+	req.RemoteAddr = "198.51.100.1:1234"
+	rec := httptest.NewRecorder()
+	app.CreateToken(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "mfa_token") {
+		t.Fatalf("CreateToken() = %d %s, want an MFA challenge", rec.Code, rec.Body.String())
+	}
+
+	// The account counter must carry on from the earlier failures
+	failures, err := attempts.Increment(context.Background(), "login:account:"+email, time.Hour)
+	if err != nil {
+		t.Fatalf("reading failures: %v", err)
+	}
+	if failures != 4 {
+		t.Errorf("account failures = %d after password login, want 4", failures)
+	}
+}
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"social-api/internal/auth"
+	"social-api/internal/store"
+)
+
+// oidcStateCookie holds the signed state of a sign-in in progress
+const oidcStateCookie = "oidc_state"
+
+// oidcStateTTL is how long a user has to complete sign-in at the provider
+const oidcStateTTL = 10 * time.Minute
+
+// errOIDCEmailConflict is returned when a provider identity cannot be linked
+// to the existing account with the same email address
+var errOIDCEmailConflict = errors.New("an account with this email address already exists; sign in with your password and verify your email address before signing in with this provider")
+
+// usernameInvalidChars matches characters not allowed in generated usernames
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// oidcState is the sign-in state kept in the signed state cookie. The PKCE
+// verifier and nonce never leave the server and the user's browser.
+type oidcState struct {
+	Provider string `json:"p"`
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Session  bool   `json:"c,omitempty"`
+}
+
+// OIDCLogin handles starting sign-in with an external OpenID Connect provider.
+// It redirects the browser to the provider; pass ?session=true to finish
+// with a browser session instead of tokens.
+func (app *Application) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	// Look up provider
+	provider, ok := app.OIDCProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Generate state, nonce and PKCE verifier
+	state, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	nonce, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	verifier, challenge, err := auth.GeneratePKCE()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Build provider URL
+	redirectURL, err := provider.AuthCodeURL(r.Context(), state, nonce, challenge)
+	if err != nil {
+		app.identityProviderErrorResponse(w, r, err)
+		return
+	}
+
+	// Sign the state into a cookie
+	data, err := json.Marshal(oidcState{
+		Provider: provider.Name(),
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		Session:  r.URL.Query().Get("session") == "true",
+	})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	signed, err := app.Signer.Sign(auth.PurposeOIDCState, 0, string(data), oidcStateTTL)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	http.SetCookie(w, app.oidcStateCookie(signed, time.Now().Add(oidcStateTTL)))
+
+	// Redirect to provider
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// OIDCCallback handles the redirect back from an external OpenID Connect
+// provider. It verifies the response, finds or creates the linked user and
+// completes the login as usual.
+func (app *Application) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	// Look up provider
+	provider, ok := app.OIDCProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// The state cookie is single use
+	cookie, err := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, app.oidcStateCookie("", time.Unix(0, 0)))
+	if err != nil {
+		app.invalidOIDCResponse(w, r)
+		return
+	}
+
+	// Verify state cookie
+	payload, err := app.Signer.Verify(cookie.Value, auth.PurposeOIDCState)
+	if err != nil {
+		app.invalidOIDCResponse(w, r)
+		return
+	}
+	var state oidcState
+	err = json.Unmarshal([]byte(payload.Data), &state)
+	if err != nil {
+		app.invalidOIDCResponse(w, r)
+		return
+	}
+
+	// The state must come back unchanged to this browser, from the provider it was sent to
+	query := r.URL.Query()
+	if state.Provider != provider.Name() || state.State == "" || query.Get("state") != state.State {
+		app.invalidOIDCResponse(w, r)
+		return
+	}
+
+	// Check for an error from the provider, such as the user declining consent
+	if providerErr := query.Get("error"); providerErr != "" {
+		app.respondError(w, http.StatusUnauthorized, fmt.Sprintf("identity provider returned %s", providerErr))
+		return
+	}
+
+	// Redeem the authorization code
+	identity, err := provider.Exchange(r.Context(), query.Get("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCProvider) {
+			app.identityProviderErrorResponse(w, r, err)
+		} else {
+			app.Logger.Printf("OIDC sign-in with %s failed: %v", provider.Name(), err)
+			app.invalidOIDCResponse(w, r)
+		}
+		return
+	}
+
+	// Find or create the linked user
+	user, err := app.oidcUser(r.Context(), provider.Name(), identity)
+	if err != nil {
+		if errors.Is(err, errOIDCEmailConflict) {
+			app.conflictResponse(w, r, err)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	// Ask for the second factor if two-factor authentication is enabled
+	mfa, err := app.MFAStore.Get(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err == nil && mfa.IsEnabled() {
+		app.mfaChallengeResponse(w, r, user)
+		return
+	}
+
+	app.completeLogin(w, r, user, state.Session)
+}
+
+// oidcUser returns the user linked to a provider identity. Unlinked
+// identities are linked to the account with the same email address when both
+// the provider and the account have verified it; otherwise a new account is
+// created.
+func (app *Application) oidcUser(ctx context.Context, provider string, identity *auth.OIDCIdentity) (*store.User, error) {
+	// Look up an existing link
+	link, err := app.UserIdentityStore.GetBySubject(ctx, provider, identity.Subject)
+	if err == nil {
+		return app.UserStore.GetByID(ctx, link.UserID)
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	if identity.Email == "" {
+		return nil, fmt.Errorf("identity provider %s did not return an email address", provider)
+	}
+
+	// Look up an account with the same email address
+	user, err := app.UserStore.GetByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		// Only link verified addresses, so that an account registered with
+		// someone else's address cannot be taken over or used to take over
+		if !identity.EmailVerified || !user.IsEmailVerified() {
+			return nil, errOIDCEmailConflict
+		}
+	case errors.Is(err, store.ErrNotFound):
+		user, err = app.createOIDCUser(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	// Link the identity
+	err = app.UserIdentityStore.Create(ctx, &store.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createOIDCUser creates an account for a new provider identity. The account
+// gets an unguessable random password, which the user can replace through a
+// password reset.
+func (app *Application) createOIDCUser(ctx context.Context, identity *auth.OIDCIdentity) (*store.User, error) {
+	password, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive a username, adding a random suffix if it is taken
+	base := oidcUsername(identity)
+	username := base
+	for attempt := 0; ; attempt++ {
+		user := &store.User{
+			Username: username,
+			Email:    identity.Email,
+			IsActive: true,
+		}
+		err = user.Password.Set(password)
+		if err != nil {
+			return nil, err
+		}
+
+		err = app.UserStore.Create(ctx, user)
+		if errors.Is(err, store.ErrDuplicateUsername) && attempt < 5 {
+			suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return nil, err
+			}
+			username = fmt.Sprintf("%s%04d", base, suffix.Int64())
+			continue
+		}
+		if errors.Is(err, store.ErrDuplicateEmail) {
+			return nil, errOIDCEmailConflict
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Trust the provider's verification, or verify the address ourselves
+		if identity.EmailVerified {
+			err = app.UserStore.MarkEmailVerified(ctx, user.ID, user.Email)
+			if err != nil {
+				return nil, err
+			}
+			return app.UserStore.GetByID(ctx, user.ID)
+		}
+
+		err = app.sendVerificationEmail(user)
+		if err != nil {
+			app.Logger.Printf("Error sending verification email: %v", err)
+		}
+		return user, nil
+	}
+}
+
+// oidcUsername derives a username from a provider identity
+func oidcUsername(identity *auth.OIDCIdentity) string {
+	name := identity.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	name = usernameInvalidChars.ReplaceAllString(name, "")
+	if len(name) < 3 {
+		name = "user" + name
+	}
+	if len(name) > 90 {
+		name = name[:90]
+	}
+
+	return name
+}
+
+// oidcStateCookie builds the state cookie. It is SameSite=Lax whatever the
+// session cookie setting, since it must survive the redirect back from the provider.
+func (app *Application) oidcStateCookie(value string, expires time.Time) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/v1/auth/oidc/",
+		Expires:  expires,
+		Secure:   app.Config.Session.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+
+	return cookie
+}
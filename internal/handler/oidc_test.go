@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/store"
+)
+
+// fakeUserStore serves users by ID and email; other methods are not used
+type fakeUserStore struct {
+	store.UserStore
+	users []*store.User
+}
+
+func (s *fakeUserStore) GetByID(ctx context.Context, id int64) (*store.User, error) {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *fakeUserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+// fakeUserIdentityStore keeps linked identities in memory
+type fakeUserIdentityStore struct {
+	identities []*store.UserIdentity
+}
+
+func (s *fakeUserIdentityStore) Create(ctx context.Context, identity *store.UserIdentity) error {
+	s.identities = append(s.identities, identity)
+	return nil
+}
+
+func (s *fakeUserIdentityStore) GetBySubject(ctx context.Context, provider, subject string) (*store.UserIdentity, error) {
+	for _, identity := range s.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func TestOIDCUserLinking(t *testing.T) {
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name             string
+		accountVerified  bool
+		providerVerified bool
+		linked           bool
+		wantErr          error
+	}{
+		{
+			name:             "both verified",
+			accountVerified:  true,
+			providerVerified: true,
+		},
+		{
+			name:             "provider unverified",
+			accountVerified:  true,
+			providerVerified: false,
+			wantErr:          errOIDCEmailConflict,
+		},
+		{
+			name:             "account unverified",
+			accountVerified:  false,
+			providerVerified: true,
+			wantErr:          errOIDCEmailConflict,
+		},
+		{
+			name:             "already linked",
+			accountVerified:  false,
+			providerVerified: false,
+			linked:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &store.User{ID: 1, Username: "alice", Email: "alice@example.com", IsActive: true}
+			if tt.accountVerified {
+				account.EmailVerifiedAt = &verifiedAt
+			}
+
+			identities := &fakeUserIdentityStore{}
+			if tt.linked {
+				identities.identities = append(identities.identities, &store.UserIdentity{
+					UserID: account.ID, Provider: "mock", Subject: "subject-1", Email: account.Email,
+				})
+			}
+
+			app := &Application{
+				UserStore:         &fakeUserStore{users: []*store.User{account}},
+				UserIdentityStore: identities,
+			}
+
+			user, err := app.oidcUser(context.Background(), "mock", &auth.OIDCIdentity{
+				Subject:       "subject-1",
+				Email:         account.Email,
+				EmailVerified: tt.providerVerified,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("oidcUser() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(identities.identities) != 0 {
+					t.Errorf("identity was linked after error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("oidcUser() error = %v", err)
+			}
+			if user.ID != account.ID {
+				t.Errorf("oidcUser() returned user %d, want %d", user.ID, account.ID)
+			}
+			if len(identities.identities) != 1 || identities.identities[0].UserID != account.ID {
+				t.Errorf("identities = %+v, want one link to user %d", identities.identities, account.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/cache"
+	"social-api/internal/mailer"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// ForgotPassword handles the forgot password endpoint.
+// It always responds with 202 Accepted so that it cannot be used to find out
+// whether an account exists for an email address.
+func (app *Application) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var input model.ForgotPasswordInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Get user by email; unknown and inactive accounts are silently ignored
+	user, err := app.UserStore.GetByEmail(r.Context(), input.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if err == nil && user.IsActive {
+		err = app.sendPasswordResetEmail(r.Context(), user)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	// Send accepted response
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordResetEmail creates a reset token for a user and emails it to them
+func (app *Application) sendPasswordResetEmail(ctx context.Context, user *store.User) error {
+	// Generate reset token
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	// Store reset token
+	reset := &store.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(app.Config.Auth.PasswordResetExpiry),
+	}
+	err = app.PasswordResetStore.Create(ctx, reset)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", app.Config.BaseURL, url.QueryEscape(token))
+
+	app.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset the password for your account. To choose a new password, open the link below:\n\n%s\n\nOr submit this token to POST /api/v1/auth/password/reset:\n\n%s\n\nThe link expires in %s. If you did not ask for a reset, you can ignore this email.\n",
+			user.Username, link, token, app.Config.Auth.PasswordResetExpiry,
+		),
+	})
+
+	return nil
+}
+
+// ResetPassword handles the reset password endpoint
+func (app *Application) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var input model.ResetPasswordInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Look up reset token
+	reset, err := app.PasswordResetStore.GetByHash(r.Context(), auth.HashToken(input.Token))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidResetTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if reset.UsedAt != nil || reset.IsExpired() {
+		app.invalidResetTokenResponse(w, r)
+		return
+	}
+
+	// Mark token as used; ErrNotFound means a concurrent request used it first
+	err = app.PasswordResetStore.MarkUsed(r.Context(), reset.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidResetTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Get user
+	user, err := app.UserStore.GetByID(r.Context(), reset.UserID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Set new password
+	err = user.Password.Set(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.UserStore.SetPassword(r.Context(), user.ID, user.Password.Hash)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Invalidate any other outstanding reset tokens
+	err = app.PasswordResetStore.InvalidateForUser(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Sign the user out everywhere
+	err = app.revokeAllSessions(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Invalidate cache
+	if app.Cache != nil {
+		err = app.Cache.Delete(r.Context(), cache.UserKey(user.ID))
+		if err != nil {
+			app.Logger.Printf("Error deleting user from cache: %v", err)
+		}
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
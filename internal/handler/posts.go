@@ -35,11 +35,19 @@ func (app *Application) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create post object
+	// Create post object, only showing posts of private accounts to their
+	// followers unless asked otherwise
 	post := &store.Post{
-		Title:   input.Title,
-		Content: input.Content,
-		UserID:  user.ID,
+		Title:      input.Title,
+		Content:    input.Content,
+		UserID:     user.ID,
+		Visibility: input.Visibility,
+	}
+	if post.Visibility == "" {
+		post.Visibility = store.VisibilityPublic
+		if user.IsPrivate {
+			post.Visibility = store.VisibilityFollowers
+		}
 	}
 
 	// Create post in database
@@ -49,26 +57,17 @@ func (app *Application) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set user for the response
+	// Set author for the response and cache
 	post.User = user
 
+	// Add post to followers' timelines
+	app.publishPost(post)
+
 	// Convert to response
-	response := model.PostResponse{
-		ID:      post.ID,
-		Title:   post.Title,
-		Content: post.Content,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-	}
-
-	// Cache post if enabled
-	if app.Cache != nil {
+	response := app.postResponse(user, post, nil)
+
+	// Cache post if enabled and every user may see it
+	if app.Cache != nil && post.IsVisibleToAll() {
 		err = app.Cache.Set(r.Context(), cache.PostKey(post.ID), post, 15*time.Minute)
 		if err != nil {
 			app.Logger.Printf("Error caching post: %v", err)
@@ -84,6 +83,9 @@ func (app *Application) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 // GetPost handles the get post endpoint
 func (app *Application) GetPost(w http.ResponseWriter, r *http.Request) {
+	// Get viewer from context
+	viewer, _ := auth.GetUserFromContext(r.Context())
+
 	// Extract post ID from URL
 	id, err := app.GetIDParam(r)
 	if err != nil {
@@ -91,26 +93,27 @@ func (app *Application) GetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Try to get post from cache
+	// Try to get post from cache; only posts every user may see are cached,
+	// since cached posts are served without checking the viewer
 	var post *store.Post
 	if app.Cache != nil {
 		var cachedPost store.Post
 		err = app.Cache.Get(r.Context(), cache.PostKey(id), &cachedPost)
-		if err == nil {
+		if err == nil && cachedPost.IsVisibleToAll() {
 			post = &cachedPost
 		}
 	}
 
 	// Get post from database if not in cache
 	if post == nil {
-		post, err = app.PostStore.GetByID(r.Context(), id)
+		post, err = app.PostStore.GetByID(r.Context(), viewer, id)
 		if err != nil {
 			app.handleError(w, r, err)
 			return
 		}
 
-		// Cache post
-		if app.Cache != nil {
+		// Cache post if every user may see it
+		if app.Cache != nil && post.IsVisibleToAll() {
 			err = app.Cache.Set(r.Context(), cache.PostKey(id), post, 15*time.Minute)
 			if err != nil {
 				app.Logger.Printf("Error caching post: %v", err)
@@ -118,21 +121,16 @@ func (app *Application) GetPost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Convert to response
-	response := model.PostResponse{
-		ID:      post.ID,
-		Title:   post.Title,
-		Content: post.Content,
-		User: model.UserResponse{
-			ID:        post.User.ID,
-			Username:  post.User.Username,
-			Email:     post.User.Email,
-			CreatedAt: post.User.CreatedAt,
-		},
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
+	// Get the viewer's reactions
+	reactions, err := app.viewerReactions(r.Context(), viewer, []*store.Post{post})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
 
+	// Convert to response
+	response := app.postResponse(viewer, post, reactions[post.ID])
+
 	// Send response
 	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
 	if err != nil {
@@ -157,14 +155,14 @@ func (app *Application) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get post from database
-	post, err := app.PostStore.GetByID(r.Context(), id)
+	post, err := app.PostStore.GetByID(r.Context(), user, id)
 	if err != nil {
 		app.handleError(w, r, err)
 		return
 	}
 
-	// Check if user is the post owner
-	if post.UserID != user.ID {
+	// Check if user is the post owner or may edit any post
+	if post.UserID != user.ID && !user.HasPermission(store.PermPostsUpdateAny) {
 		app.forbiddenResponse(w, r)
 		return
 	}
@@ -192,6 +190,10 @@ func (app *Application) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	if input.Content != nil {
 		post.Content = *input.Content
 	}
+	wasPrivate := post.Visibility == store.VisibilityPrivate
+	if input.Visibility != nil {
+		post.Visibility = *input.Visibility
+	}
 
 	// Update post in database
 	err = app.PostStore.Update(r.Context(), post)
@@ -208,21 +210,21 @@ func (app *Application) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Convert to response
-	response := model.PostResponse{
-		ID:      post.ID,
-		Title:   post.Title,
-		Content: post.Content,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
+	// A private post that is shared now joins followers' timelines
+	if wasPrivate && post.Visibility != store.VisibilityPrivate {
+		app.publishPost(post)
 	}
 
+	// Get the user's reactions
+	reactions, err := app.viewerReactions(r.Context(), user, []*store.Post{post})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to response
+	response := app.postResponse(user, post, reactions[post.ID])
+
 	// Send response
 	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
 	if err != nil {
@@ -247,14 +249,14 @@ func (app *Application) DeletePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get post from database
-	post, err := app.PostStore.GetByID(r.Context(), id)
+	post, err := app.PostStore.GetByID(r.Context(), user, id)
 	if err != nil {
 		app.handleError(w, r, err)
 		return
 	}
 
-	// Check if user is the post owner
-	if post.UserID != user.ID {
+	// Check if user is the post owner or may remove any post
+	if post.UserID != user.ID && !user.HasPermission(store.PermPostsDeleteAny) {
 		app.forbiddenResponse(w, r)
 		return
 	}
@@ -280,6 +282,9 @@ func (app *Application) DeletePost(w http.ResponseWriter, r *http.Request) {
 
 // ListPosts handles the list posts endpoint
 func (app *Application) ListPosts(w http.ResponseWriter, r *http.Request) {
+	// Get viewer from context
+	viewer, _ := auth.GetUserFromContext(r.Context())
+
 	// Get pagination params
 	pagination := model.GetPagination(r)
 
@@ -305,7 +310,14 @@ func (app *Application) ListPosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get posts from database
-	posts, totalCount, err := app.PostStore.List(r.Context(), pagination, filter)
+	posts, totalCount, err := app.PostStore.List(r.Context(), viewer, pagination, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Get the viewer's reactions
+	reactions, err := app.viewerReactions(r.Context(), viewer, posts)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -314,19 +326,7 @@ func (app *Application) ListPosts(w http.ResponseWriter, r *http.Request) {
 	// Convert to responses
 	postResponses := make([]model.PostResponse, len(posts))
 	for i, post := range posts {
-		postResponses[i] = model.PostResponse{
-			ID:      post.ID,
-			Title:   post.Title,
-			Content: post.Content,
-			User: model.UserResponse{
-				ID:        post.User.ID,
-				Username:  post.User.Username,
-				Email:     post.User.Email,
-				CreatedAt: post.User.CreatedAt,
-			},
-			CreatedAt: post.CreatedAt,
-			UpdatedAt: post.UpdatedAt,
-		}
+		postResponses[i] = app.postResponse(viewer, post, reactions[post.ID])
 	}
 
 	// Send response with pagination
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"social-api/internal/auth"
+	"social-api/internal/model"
+)
+
+// GetUserProfile handles viewing a user's profile by username. The fields
+// returned depend on the viewer; see userResponse.
+func (app *Application) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	// Get viewer from context
+	viewer, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get user by username
+	user, err := app.UserStore.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Deactivated accounts have no public profile
+	if !user.IsActive && user.ID != viewer.ID {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Create response
+	response, err := app.profileResponse(r.Context(), viewer, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// directorySortColumns are the sort_by values accepted by the user directory
+var directorySortColumns = map[string]bool{
+	"username":   true,
+	"created_at": true,
+}
+
+// ListUsers handles the user directory endpoint.
+// Supports ?q= to find usernames starting with a prefix. Only active users are listed.
+func (app *Application) ListUsers(w http.ResponseWriter, r *http.Request) {
+	// Get viewer from context
+	viewer, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get pagination params, listing users alphabetically by default. Only
+	// public fields can be sorted by, so the order cannot reveal hidden ones.
+	pagination := model.GetPagination(r)
+	if r.URL.Query().Get("sort_by") == "" || !directorySortColumns[pagination.SortBy] {
+		pagination.SortBy = "username"
+		if r.URL.Query().Get("sort") == "" {
+			pagination.Sort = "asc"
+		}
+	}
+
+	// Get filter params
+	active := true
+	filter := model.UserFilter{IsActive: &active}
+	if prefix := r.URL.Query().Get("q"); prefix != "" {
+		filter.UsernamePrefix = &prefix
+	}
+
+	// Get users from database
+	users, totalCount, err := app.UserStore.List(r.Context(), pagination, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = app.userResponse(viewer, user)
+	}
+
+	// Send response with pagination
+	err = model.WriteJSON(
+		w,
+		http.StatusOK,
+		model.NewPageResponse(
+			responses,
+			pagination.Page,
+			pagination.PageSize,
+			totalCount,
+		),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
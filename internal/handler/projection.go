@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// relation returns the viewer's relationship to a user. A nil viewer is
+// treated as any other user.
+func relation(viewer, user *store.User) model.Relation {
+	switch {
+	case viewer == nil:
+		return model.RelationOther
+	case viewer.ID == user.ID:
+		return model.RelationSelf
+	case viewer.HasPermission(store.PermUsersManage):
+		return model.RelationAdmin
+	default:
+		return model.RelationOther
+	}
+}
+
+// userResponse converts a user to its response representation, leaving out
+// the fields the viewer may not see
+func (app *Application) userResponse(viewer, user *store.User) model.UserResponse {
+	rel := relation(viewer, user)
+	return projectUser(user, func(field string) bool {
+		return app.Projection.Shows(rel, field)
+	})
+}
+
+// projectUser converts a user to its response representation with the fields shows allows
+func projectUser(user *store.User, shows func(field string) bool) model.UserResponse {
+	response := model.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		IsPrivate: user.IsPrivate,
+	}
+	if shows(model.UserFieldEmail) {
+		response.Email = user.Email
+	}
+	if shows(model.UserFieldRoles) {
+		response.Roles = user.Roles
+	}
+	if shows(model.UserFieldDisplayName) {
+		response.DisplayName = &user.DisplayName
+	}
+	if shows(model.UserFieldBio) {
+		response.Bio = &user.Bio
+	}
+	if shows(model.UserFieldAvatarURL) {
+		response.AvatarURL = &user.AvatarURL
+	}
+	if shows(model.UserFieldEmailVerifiedAt) {
+		response.EmailVerifiedAt = user.EmailVerifiedAt
+	}
+
+	return response
+}
+
+// postResponse converts a post to its response representation, with the
+// author projected for the viewer and the kinds of reaction the viewer left
+func (app *Application) postResponse(viewer *store.User, post *store.Post, reactions []string) model.PostResponse {
+	return model.PostResponse{
+		ID:         post.ID,
+		Title:      post.Title,
+		Content:    post.Content,
+		Visibility: post.Visibility,
+		User:       app.userResponse(viewer, post.User),
+		Reactions:  reactionsResponse(post, reactions),
+		CreatedAt:  post.CreatedAt,
+		UpdatedAt:  post.UpdatedAt,
+	}
+}
+
+// reactionsResponse summarizes the reactions to a post
+func reactionsResponse(post *store.Post, mine []string) model.ReactionsResponse {
+	response := model.ReactionsResponse{
+		Counts: post.ReactionCounts,
+		Mine:   mine,
+	}
+	if response.Counts == nil {
+		response.Counts = map[string]int{}
+	}
+	if response.Mine == nil {
+		response.Mine = []string{}
+	}
+
+	return response
+}
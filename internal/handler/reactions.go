@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"social-api/internal/auth"
+	"social-api/internal/cache"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// AddReaction handles reacting to a post. Reacting again with the same kind
+// has no effect.
+func (app *Application) AddReaction(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, true)
+}
+
+// RemoveReaction handles removing a reaction from a post. Removing a
+// reaction that was never left has no effect.
+func (app *Application) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	app.setReaction(w, r, false)
+}
+
+// setReaction adds or removes the current user's reaction of the kind in the URL
+func (app *Application) setReaction(w http.ResponseWriter, r *http.Request, add bool) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract post ID and reaction kind from URL
+	postID, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	kind := chi.URLParam(r, "kind")
+	if !model.IsReactionKind(kind) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Check post exists
+	_, err = app.PostStore.GetByID(r.Context(), user, postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Update reaction in database
+	if add {
+		err = app.ReactionStore.Add(r.Context(), &store.Reaction{
+			PostID: postID,
+			UserID: user.ID,
+			Kind:   kind,
+		})
+	} else {
+		err = app.ReactionStore.Remove(r.Context(), postID, user.ID, kind)
+		if errors.Is(err, store.ErrNotFound) {
+			err = nil
+		}
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Invalidate cache, since cached posts carry their reaction counts
+	if app.Cache != nil {
+		err = app.Cache.Delete(r.Context(), cache.PostKey(postID))
+		if err != nil {
+			app.Logger.Printf("Error deleting post from cache: %v", err)
+		}
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListReactions handles listing who reacted to a post, newest first.
+// Supports ?kind= to only list reactions of one kind.
+func (app *Application) ListReactions(w http.ResponseWriter, r *http.Request) {
+	// Get viewer from context
+	viewer, _ := auth.GetUserFromContext(r.Context())
+
+	// Extract post ID from URL
+	postID, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Get pagination params
+	pagination := model.GetPagination(r)
+
+	// Get filter params
+	var kind *string
+	if k := r.URL.Query().Get("kind"); k != "" {
+		if !model.IsReactionKind(k) {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "kind", Message: "Must be a known reaction kind"},
+			})
+			return
+		}
+		kind = &k
+	}
+
+	// Check post exists
+	_, err = app.PostStore.GetByID(r.Context(), viewer, postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Get reactions from database
+	reactions, totalCount, err := app.ReactionStore.ListByPost(r.Context(), postID, kind, pagination)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Convert to responses
+	responses := make([]model.ReactionResponse, len(reactions))
+	for i, reaction := range reactions {
+		responses[i] = model.ReactionResponse{
+			User:      app.userResponse(viewer, reaction.User),
+			Kind:      reaction.Kind,
+			CreatedAt: reaction.CreatedAt,
+		}
+	}
+
+	// Send response with pagination
+	err = model.WriteJSON(
+		w,
+		http.StatusOK,
+		model.NewPageResponse(
+			responses,
+			pagination.Page,
+			pagination.PageSize,
+			totalCount,
+		),
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// viewerReactions retrieves the kinds of reaction the viewer left on each post
+func (app *Application) viewerReactions(ctx context.Context, viewer *store.User, posts []*store.Post) (map[int64][]string, error) {
+	if viewer == nil {
+		return map[int64][]string{}, nil
+	}
+
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	return app.ReactionStore.KindsByUser(ctx, viewer.ID, postIDs)
+}
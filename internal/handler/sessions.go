@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/cache"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// completeLogin finishes a successful login by issuing either a token pair
+// or, when the client asked for one, a browser session cookie
+func (app *Application) completeLogin(w http.ResponseWriter, r *http.Request, user *store.User, session bool) {
+	// Logging in cancels a pending account deletion
+	err := app.cancelAccountDeletion(r, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var response interface{}
+	if session {
+		// Start a browser session
+		response, err = app.startSession(w, r, user)
+	} else {
+		// Start a token login session with access and refresh tokens
+		response, err = app.startTokenSession(r, user)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Cache user if enabled
+	if app.Cache != nil {
+		err = app.Cache.Set(r.Context(), cache.UserKey(user.ID), user, 1*time.Hour)
+		if err != nil {
+			app.Logger.Printf("Error caching user: %v", err)
+		}
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// startSession creates a server-side session for a user and sets the
+// session and CSRF cookies
+func (app *Application) startSession(w http.ResponseWriter, r *http.Request, user *store.User) (*model.SessionLoginResponse, error) {
+	// Generate session token
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate CSRF token
+	csrfToken, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Store session
+	session, err := app.createSession(r, user, store.SessionKindCookie, hash, app.Config.Session.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set cookies; the CSRF cookie must be readable by the frontend
+	http.SetCookie(w, app.sessionCookie(app.Config.Session.CookieName, token, session.ExpiresAt, true))
+	http.SetCookie(w, app.sessionCookie(app.Config.Session.CSRFCookieName, csrfToken, session.ExpiresAt, false))
+
+	response := &model.SessionLoginResponse{
+		User:      app.userResponse(user, user),
+		CSRFToken: csrfToken,
+		ExpiresAt: session.ExpiresAt,
+	}
+
+	return response, nil
+}
+
+// createSession records a new login session with the client's IP and user agent
+func (app *Application) createSession(r *http.Request, user *store.User, kind, tokenHash string, expiry time.Duration) (*store.Session, error) {
+	session := &store.Session{
+		UserID:    user.ID,
+		Kind:      kind,
+		TokenHash: tokenHash,
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+		ExpiresAt: time.Now().Add(expiry),
+	}
+
+	err := app.SessionStore.Create(r.Context(), session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// endSession revokes a user's session together with its refresh tokens.
+// Access tokens issued for the session are rejected by the revocation list.
+func (app *Application) endSession(ctx context.Context, sessionID, userID int64) error {
+	err := app.Revocations.RevokeSession(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	return app.RefreshTokenStore.RevokeSession(ctx, sessionID)
+}
+
+// clearSessionCookies expires the session and CSRF cookies in the browser
+func (app *Application) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, app.sessionCookie(app.Config.Session.CookieName, "", time.Unix(0, 0), true))
+	http.SetCookie(w, app.sessionCookie(app.Config.Session.CSRFCookieName, "", time.Unix(0, 0), false))
+}
+
+// sessionCookie builds a session or CSRF cookie with the configured attributes
+func (app *Application) sessionCookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   app.Config.Session.CookieDomain,
+		Expires:  expires,
+		Secure:   app.Config.Session.CookieSecure,
+		HttpOnly: httpOnly,
+	}
+
+	switch strings.ToLower(app.Config.Session.CookieSameSite) {
+	case "strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "none":
+		cookie.SameSite = http.SameSiteNoneMode
+	default:
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+
+	return cookie
+}
+
+// ListSessions handles listing the current user's active sessions, covering
+// both browser sessions and token logins
+func (app *Application) ListSessions(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Get sessions from database
+	sessions, err := app.SessionStore.ListActiveByUser(r.Context(), user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Mark the session making this request
+	var currentID int64
+	if identity, ok := auth.GetIdentityFromContext(r.Context()); ok {
+		currentID = identity.SessionID
+	}
+
+	// Convert to responses
+	responses := make([]model.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = model.SessionResponse{
+			ID:         session.ID,
+			Kind:       session.Kind,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == currentID,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			CreatedAt:  session.CreatedAt,
+		}
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(responses))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteSession handles remotely signing out one of the current user's sessions
+func (app *Application) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Extract session ID from URL
+	id, err := app.GetIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Revoke session
+	err = app.endSession(r.Context(), id, user.ID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Clear cookies if the current session was revoked
+	if identity, ok := auth.GetIdentityFromContext(r.Context()); ok && identity.SessionID == id {
+		app.clearSessionCookies(w)
+	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"net/url"
 	"time"
 
 	"social-api/internal/auth"
@@ -57,23 +59,17 @@ func (app *Application) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate token
-	token, err := app.Authenticator.GenerateToken(user.ID, app.Config.Auth.TokenExpiry)
+	// Send verification email
+	err = app.sendVerificationEmail(user)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
+		app.Logger.Printf("Error sending verification email: %v", err)
 	}
 
-	// Create response
-	response := model.TokenResponse{
-		Token: token,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
-		ExpiresAt: time.Now().Add(app.Config.Auth.TokenExpiry),
+	// Generate access and refresh tokens
+	response, err := app.startTokenSession(r, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
 
 	// Cache user if enabled
@@ -109,11 +105,23 @@ func (app *Application) CreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject attempts while the account or client IP is locked out
+	ip := clientIP(r)
+	lockout, err := app.LoginGuard.Check(r.Context(), input.Email, ip)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if lockout != nil {
+		app.lockedOutResponse(w, r, lockout)
+		return
+	}
+
 	// Get user by email
 	user, err := app.UserStore.GetByEmail(r.Context(), input.Email)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
-			app.unauthorizedResponse(w, r)
+			app.loginFailedResponse(w, r, input.Email, ip, nil)
 		} else {
 			app.serverErrorResponse(w, r, err)
 		}
@@ -121,44 +129,215 @@ func (app *Application) CreateToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check password
-	match, err := user.Password.Matches(input.Password)
+	match, outdated, err := user.Password.Matches(input.Password)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	if !match {
-		app.unauthorizedResponse(w, r)
+		app.loginFailedResponse(w, r, input.Email, ip, user)
 		return
 	}
 
-	// Generate token
-	token, err := app.Authenticator.GenerateToken(user.ID, app.Config.Auth.TokenExpiry)
+	// Upgrade a hash made with old parameters while the plaintext is at hand
+	if outdated {
+		app.rehashPassword(r.Context(), user, input.Password)
+	}
+
+	// Ask for the second factor if two-factor authentication is enabled. The
+	// failure counter is only cleared once it has been verified, so that
+	// logging in with the password again cannot reset failed code guesses.
+	mfa, err := app.MFAStore.Get(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err == nil && mfa.IsEnabled() {
+		app.mfaChallengeResponse(w, r, user)
+		return
+	}
+
+	// Clear the account's failure counter
+	err = app.LoginGuard.Succeed(r.Context(), input.Email)
+	if err != nil {
+		app.Logger.Printf("Error resetting login failures: %v", err)
+	}
+
+	app.completeLogin(w, r, user, input.Session)
+}
+
+// rehashPassword hashes a user's password with the current parameters and
+// saves it. Failures are only logged, since the old hash still works.
+func (app *Application) rehashPassword(ctx context.Context, user *store.User, plaintext string) {
+	oldHash := user.Password.Hash
+
+	err := user.Password.Set(plaintext)
+	if err != nil {
+		app.Logger.Printf("Error rehashing password for user %d: %v", user.ID, err)
+		return
+	}
+
+	err = app.UserStore.UpdatePasswordHash(ctx, user.ID, oldHash, user.Password.Hash)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.Logger.Printf("Error saving rehashed password for user %d: %v", user.ID, err)
+	}
+}
+
+// loginFailedResponse records a failed login and responds with 401, or with
+// the lockout the failure triggered. Lockouts are written to the audit log.
+func (app *Application) loginFailedResponse(w http.ResponseWriter, r *http.Request, email, ip string, user *store.User) {
+	lockout, err := app.LoginGuard.Fail(r.Context(), email, ip)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	// Create response
-	response := model.TokenResponse{
-		Token: token,
-		User: model.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
+	if lockout == nil {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Record the lockout
+	event := &store.AuditEvent{
+		Event: store.AuditLoginLocked,
+		IP:    ip,
+		Details: map[string]interface{}{
+			"scope":          lockout.Scope,
+			"email":          email,
+			"failures":       lockout.Failures,
+			"locked_seconds": int(lockout.RetryAfter.Seconds()),
 		},
-		ExpiresAt: time.Now().Add(app.Config.Auth.TokenExpiry),
+	}
+	if user != nil {
+		event.UserID = &user.ID
 	}
 
-	// Cache user if enabled
+	err = app.AuditStore.Create(r.Context(), event)
+	if err != nil {
+		app.Logger.Printf("Error writing audit event: %v", err)
+	}
+
+	app.Logger.Printf("Login locked out (%s) for %s from %s after %d failures", lockout.Scope, email, ip, lockout.Failures)
+	app.lockedOutResponse(w, r, lockout)
+}
+
+// GetCurrentUser handles the current user endpoint
+func (app *Application) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Create response
+	response, err := app.profileResponse(r.Context(), user, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// UpdateCurrentUser handles the update current user endpoint
+func (app *Application) UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Parse request body
+	var input model.UserUpdateInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Apply updates if provided
+	emailChanged := false
+	madePublic := false
+	if input.Username != nil {
+		user.Username = *input.Username
+	}
+	if input.Email != nil && *input.Email != user.Email {
+		user.Email = *input.Email
+		emailChanged = true
+	}
+	if input.DisplayName != nil {
+		user.DisplayName = *input.DisplayName
+	}
+	if input.Bio != nil {
+		user.Bio = *input.Bio
+	}
+	if input.AvatarURL != nil {
+		if *input.AvatarURL != "" && !isWebURL(*input.AvatarURL) {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "avatar_url", Message: "Must be an http or https URL"},
+			})
+			return
+		}
+		user.AvatarURL = *input.AvatarURL
+	}
+	if input.IsPrivate != nil {
+		madePublic = user.IsPrivate && !*input.IsPrivate
+		user.IsPrivate = *input.IsPrivate
+	}
+
+	// Update user in database
+	err = app.UserStore.Update(r.Context(), user)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// A public account has no follow requests to approve
+	if madePublic {
+		followerIDs, err := app.FollowStore.AcceptAll(r.Context(), user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		app.invalidateFeeds(r.Context(), followerIDs...)
+	}
+
+	// A new email address has to be verified again
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+
+		err = app.sendVerificationEmail(user)
+		if err != nil {
+			app.Logger.Printf("Error sending verification email: %v", err)
+		}
+	}
+
+	// Invalidate cache
 	if app.Cache != nil {
-		err = app.Cache.Set(r.Context(), cache.UserKey(user.ID), user, 1*time.Hour)
+		err = app.Cache.Delete(r.Context(), cache.UserKey(user.ID))
 		if err != nil {
-			app.Logger.Printf("Error caching user: %v", err)
+			app.Logger.Printf("Error deleting user from cache: %v", err)
 		}
 	}
 
+	// Create response
+	response := app.userResponse(user, user)
+
 	// Send response
 	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
 	if err != nil {
@@ -166,8 +345,15 @@ func (app *Application) CreateToken(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetCurrentUser handles the current user endpoint
-func (app *Application) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+// isWebURL reports whether a string is an absolute http or https URL
+// without embedded credentials
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
+
+// ChangePassword handles the change password endpoint
+func (app *Application) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// Get user from context
 	user, ok := auth.GetUserFromContext(r.Context())
 	if !ok {
@@ -175,17 +361,65 @@ func (app *Application) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create response
-	response := model.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+	// Parse request body
+	var input model.ChangePasswordInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
 	}
 
-	// Send response
-	err := model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Check current password
+	match, _, err := user.Password.Matches(input.CurrentPassword)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !match {
+		app.validationErrorResponse(w, r, []ValidationError{
+			{Field: "current_password", Message: "Current password is incorrect"},
+		})
+		return
+	}
+
+	// Set new password
+	oldHash := user.Password.Hash
+	err = user.Password.Set(input.NewPassword)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Update password in database, unless it was changed since it was checked
+	err = app.UserStore.UpdatePasswordHash(r.Context(), user.ID, oldHash, user.Password.Hash)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.validationErrorResponse(w, r, []ValidationError{
+				{Field: "current_password", Message: "Current password is incorrect"},
+			})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Invalidate cache
+	if app.Cache != nil {
+		err = app.Cache.Delete(r.Context(), cache.UserKey(user.ID))
+		if err != nil {
+			app.Logger.Printf("Error deleting user from cache: %v", err)
+		}
 	}
+
+	// Send no content response
+	w.WriteHeader(http.StatusNoContent)
 }
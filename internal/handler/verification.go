@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"social-api/internal/auth"
+	"social-api/internal/cache"
+	"social-api/internal/mailer"
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// sendMail delivers a message in the background so a slow mail server does not delay the response
+func (app *Application) sendMail(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		err := app.Mailer.Send(ctx, msg)
+		if err != nil {
+			app.Logger.Printf("Error sending email to %s: %v", msg.To, err)
+		}
+	}()
+}
+
+// sendVerificationEmail sends a signed email verification link to a user
+func (app *Application) sendVerificationEmail(user *store.User) error {
+	// Sign a token bound to the current email address
+	token, err := app.Signer.Sign(auth.PurposeEmailVerification, user.ID, user.Email, app.Config.Auth.EmailVerificationExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", app.Config.BaseURL, url.QueryEscape(token))
+
+	app.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nOr submit this token to POST /api/v1/users/verify:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, link, token, app.Config.Auth.EmailVerificationExpiry,
+		),
+	})
+
+	return nil
+}
+
+// VerifyEmail handles the email verification endpoint
+func (app *Application) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var input model.VerifyEmailInput
+	err := model.ReadJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate input
+	err = app.ValidateRequest(input)
+	if err != nil {
+		validationErrors := app.FormatValidationErrors(err)
+		app.validationErrorResponse(w, r, validationErrors)
+		return
+	}
+
+	// Verify token signature, purpose and expiry
+	payload, err := app.Signer.Verify(input.Token, auth.PurposeEmailVerification)
+	if err != nil {
+		app.invalidVerificationTokenResponse(w, r)
+		return
+	}
+
+	// Mark email as verified; this fails if the token was already used
+	// or the user has changed their email since it was issued
+	err = app.UserStore.MarkEmailVerified(r.Context(), payload.UserID, payload.Data)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			app.invalidVerificationTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Get updated user
+	user, err := app.UserStore.GetByID(r.Context(), payload.UserID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// Invalidate cache
+	if app.Cache != nil {
+		err = app.Cache.Delete(r.Context(), cache.UserKey(user.ID))
+		if err != nil {
+			app.Logger.Printf("Error deleting user from cache: %v", err)
+		}
+	}
+
+	// Create response
+	response := app.userResponse(user, user)
+
+	// Send response
+	err = model.WriteJSON(w, http.StatusOK, model.NewResponse(response))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// ResendVerificationEmail handles the resend verification email endpoint
+func (app *Application) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		app.unauthorizedResponse(w, r)
+		return
+	}
+
+	// Nothing to do for verified accounts
+	if user.IsEmailVerified() {
+		app.conflictResponse(w, r, errors.New("email address is already verified"))
+		return
+	}
+
+	// Send verification email
+	err := app.sendVerificationEmail(user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Send accepted response
+	w.WriteHeader(http.StatusAccepted)
+}
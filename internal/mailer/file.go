@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer implements the Mailer interface by writing each message to an
+// .eml file in a directory. It is intended for local development and testing.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a new file mailer, creating the directory if needed
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+// Send writes the message to a new file
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	// Name files by time and recipient so they sort chronologically
+	recipient := strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To)
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), recipient)
+
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o644)
+}
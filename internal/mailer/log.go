@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer implements the Mailer interface by writing messages to a logger.
+// It is intended for local development.
+type LogMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer creates a new log mailer
+func NewLogMailer(logger *log.Logger) *LogMailer {
+	return &LogMailer{
+		logger: logger,
+	}
+}
+
+// Send logs the message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Printf("MAIL: to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
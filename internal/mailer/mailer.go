@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"social-api/internal/config"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer defines the interface for sending email
+type Mailer interface {
+	// Send delivers a message
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates the mailer selected by the configured backend
+func New(cfg config.MailerConfig, logger *log.Logger) (Mailer, error) {
+	switch cfg.Backend {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From, cfg.SMTPTimeout)
+	case "file":
+		return NewFileMailer(cfg.FileDir, cfg.From)
+	case "log", "":
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer backend: %q", cfg.Backend)
+	}
+}
+
+// format renders a message in RFC 5322 format
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
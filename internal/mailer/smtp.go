@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer implements the Mailer interface using an SMTP server
+type SMTPMailer struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    string
+	sender  string
+	timeout time.Duration
+}
+
+// NewSMTPMailer creates a new SMTP mailer. From is an RFC 5322 address such
+// as "Social API <no-reply@example.com>". Authentication is only used when a
+// username is given. Each message must be delivered within timeout.
+func NewSMTPMailer(host, port, username, password, from string, timeout time.Duration) (*SMTPMailer, error) {
+	// The envelope sender is the bare address
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		host:    host,
+		addr:    net.JoinHostPort(host, port),
+		auth:    auth,
+		from:    from,
+		sender:  sender.Address,
+		timeout: timeout,
+	}, nil
+}
+
+// Send delivers a message through the SMTP server, giving up when the
+// timeout passes or the context is done
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	// Connect to the server
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Bound the whole conversation, and abort it if the context is cancelled
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Upgrade to TLS when the server supports it, as smtp.SendMail does
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: m.host})
+		if err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		err = client.Auth(m.auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Send the message
+	err = client.Mail(m.sender)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(msg.To)
+	if err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(format(m.from, msg))
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
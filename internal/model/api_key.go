@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// APIKeyInput represents input for API key creation
+type APIKeyInput struct {
+	Name      string     `json:"name" validate:"required,min=1,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key in responses
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyCreatedResponse represents a newly created API key. The key itself
+// is only ever returned in this response.
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// DefaultCommentDepth is the default number of reply levels listed below each comment
+const DefaultCommentDepth = 3
+
+// MaxCommentDepth is the maximum number of reply levels listed below each comment
+const MaxCommentDepth = 10
+
+// CommentInput represents input for comment creation
+type CommentInput struct {
+	Content  string `json:"content" validate:"required,max=10000"`
+	ParentID *int64 `json:"parent_id" validate:"omitempty,min=1"`
+}
+
+// CommentUpdateInput represents input for comment update
+type CommentUpdateInput struct {
+	Content string `json:"content" validate:"required,max=10000"`
+}
+
+// CommentResponse represents a comment in responses. Replies is only set
+// when comments are listed as a tree.
+type CommentResponse struct {
+	ID         int64             `json:"id"`
+	PostID     int64             `json:"post_id"`
+	ParentID   *int64            `json:"parent_id"`
+	Content    string            `json:"content"`
+	User       UserResponse      `json:"user"`
+	Depth      int               `json:"depth"`
+	ReplyCount int               `json:"reply_count"`
+	Replies    []CommentResponse `json:"replies,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
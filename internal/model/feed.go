@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the API
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor encodes the ID of the last item on a page as an opaque cursor
+func EncodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// DecodeCursor decodes a cursor made by EncodeCursor
+func DecodeCursor(cursor string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id < 1 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// FollowResponse represents a follow in responses. User is the user on the
+// other side of the follow: the followed user, or the follower in lists of
+// followers and follow requests.
+type FollowResponse struct {
+	User      UserResponse `json:"user"`
+	Status    string       `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+}
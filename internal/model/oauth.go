@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// OAuthClientInput represents input for registering an OAuth2 client.
+// Confidential clients receive a secret; public clients such as mobile and
+// single-page apps rely on PKCE alone.
+type OAuthClientInput struct {
+	Name         string   `json:"name" validate:"required,min=1,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,max=10,dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=posts:read posts:write"`
+	Confidential bool     `json:"confidential"`
+}
+
+// OAuthClientResponse represents an OAuth2 client in responses
+type OAuthClientResponse struct {
+	ID           int64     `json:"id"`
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// OAuthClientCreatedResponse represents a newly registered OAuth2 client.
+// The client secret is only ever returned in this response.
+type OAuthClientCreatedResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorizeInput represents an OAuth2 authorization request. It is read
+// from the query string when showing the consent screen and from the body
+// when the user approves or denies it.
+type OAuthAuthorizeInput struct {
+	ResponseType        string `json:"response_type" validate:"required,eq=code"`
+	ClientID            string `json:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" validate:"required,url"`
+	Scope               string `json:"scope" validate:"required"`
+	State               string `json:"state" validate:"max=500"`
+	CodeChallenge       string `json:"code_challenge" validate:"required,min=43,max=128"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required,eq=S256"`
+	Approve             bool   `json:"approve"`
+}
+
+// OAuthConsentResponse describes an authorization request for the consent screen
+type OAuthConsentResponse struct {
+	ClientID    string   `json:"client_id"`
+	ClientName  string   `json:"client_name"`
+	Scopes      []string `json:"scopes"`
+	RedirectURI string   `json:"redirect_uri"`
+}
+
+// OAuthAuthorizeResponse holds the client URL to send the browser to after consent
+type OAuthAuthorizeResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// OAuthTokenResponse represents an OAuth2 token endpoint response (RFC 6749 section 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthErrorResponse represents an OAuth2 token endpoint error (RFC 6749 section 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
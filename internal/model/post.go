@@ -6,22 +6,28 @@ import "time"
 type PostInput struct {
 	Title   string `json:"title" validate:"required,min=3,max=200"`
 	Content string `json:"content" validate:"required,min=10"`
+
+	// Visibility defaults to public, or followers for private accounts
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public unlisted followers private"`
 }
 
 // PostUpdateInput represents input for post update
 type PostUpdateInput struct {
-	Title   *string `json:"title" validate:"omitempty,min=3,max=200"`
-	Content *string `json:"content" validate:"omitempty,min=10"`
+	Title      *string `json:"title" validate:"omitempty,min=3,max=200"`
+	Content    *string `json:"content" validate:"omitempty,min=10"`
+	Visibility *string `json:"visibility" validate:"omitempty,oneof=public unlisted followers private"`
 }
 
 // PostResponse represents a post in responses
 type PostResponse struct {
-	ID        int64        `json:"id"`
-	Title     string       `json:"title"`
-	Content   string       `json:"content"`
-	User      UserResponse `json:"user"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+	ID         int64             `json:"id"`
+	Title      string            `json:"title"`
+	Content    string            `json:"content"`
+	Visibility string            `json:"visibility"`
+	User       UserResponse      `json:"user"`
+	Reactions  ReactionsResponse `json:"reactions"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
 }
 
 // PostFilter represents filters for post queries
@@ -0,0 +1,65 @@
+package model
+
+import "fmt"
+
+// Relation is a viewer's relationship to the user in a response
+type Relation int
+
+// Relations a viewer can have to a user
+const (
+	RelationOther Relation = iota
+	RelationSelf
+	RelationAdmin
+)
+
+// User fields that can be hidden from viewers
+const (
+	UserFieldEmail           = "email"
+	UserFieldRoles           = "roles"
+	UserFieldDisplayName     = "display_name"
+	UserFieldBio             = "bio"
+	UserFieldAvatarURL       = "avatar_url"
+	UserFieldEmailVerifiedAt = "email_verified_at"
+)
+
+var userFields = map[string]bool{
+	UserFieldEmail:           true,
+	UserFieldRoles:           true,
+	UserFieldDisplayName:     true,
+	UserFieldBio:             true,
+	UserFieldAvatarURL:       true,
+	UserFieldEmailVerifiedAt: true,
+}
+
+// UserProjection decides which user fields each viewer may see. The email
+// address is always hidden from other users, whatever the configuration.
+type UserProjection struct {
+	hidden map[Relation]map[string]bool
+}
+
+// NewUserProjection creates a projection hiding the given fields from the
+// user themselves, from admins and from other users
+func NewUserProjection(hiddenFromSelf, hiddenFromAdmins, hiddenFromOthers []string) (*UserProjection, error) {
+	p := &UserProjection{hidden: make(map[Relation]map[string]bool)}
+
+	for relation, fields := range map[Relation][]string{
+		RelationSelf:  hiddenFromSelf,
+		RelationAdmin: hiddenFromAdmins,
+		RelationOther: append([]string{UserFieldEmail}, hiddenFromOthers...),
+	} {
+		p.hidden[relation] = make(map[string]bool)
+		for _, field := range fields {
+			if !userFields[field] {
+				return nil, fmt.Errorf("unknown user field %q", field)
+			}
+			p.hidden[relation][field] = true
+		}
+	}
+
+	return p, nil
+}
+
+// Shows reports whether a viewer with the given relation may see a user field
+func (p *UserProjection) Shows(relation Relation, field string) bool {
+	return !p.hidden[relation][field]
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ReactionKinds are the kinds of reaction users can leave on posts
+var ReactionKinds = []string{"like", "love", "laugh", "wow", "sad", "angry"}
+
+// IsReactionKind reports whether kind is one of ReactionKinds
+func IsReactionKind(kind string) bool {
+	for _, k := range ReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionsResponse summarizes the reactions to a post: the number of each
+// kind, and the kinds the viewer reacted with
+type ReactionsResponse struct {
+	Counts map[string]int `json:"counts"`
+	Mine   []string       `json:"mine"`
+}
+
+// ReactionResponse represents a user's reaction in responses
+type ReactionResponse struct {
+	User      UserResponse `json:"user"`
+	Kind      string       `json:"kind"`
+	CreatedAt time.Time    `json:"created_at"`
+}
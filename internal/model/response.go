@@ -13,6 +13,10 @@ type Metadata struct {
 	FirstPage    int `json:"first_page,omitempty"`
 	LastPage     int `json:"last_page,omitempty"`
 	TotalRecords int `json:"total_records,omitempty"`
+
+	// NextCursor fetches the next page of cursor-paginated responses; it is
+	// omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // NewResponse creates a new API response
@@ -41,3 +45,14 @@ func NewPageResponse(data interface{}, page, pageSize, total int) Response {
 		},
 	}
 }
+
+// NewCursorResponse creates a new cursor-paginated API response
+func NewCursorResponse(data interface{}, pageSize int, nextCursor string) Response {
+	return Response{
+		Data: data,
+		Meta: &Metadata{
+			PageSize:   pageSize,
+			NextCursor: nextCursor,
+		},
+	}
+}
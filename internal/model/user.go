@@ -9,23 +9,179 @@ type UserInput struct {
 	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
-// UserLoginInput represents input for user login
+// UserUpdateInput represents input for updating the current user's profile
+type UserUpdateInput struct {
+	Username    *string `json:"username" validate:"omitempty,min=3,max=100"`
+	Email       *string `json:"email" validate:"omitempty,email,max=255"`
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	Bio         *string `json:"bio" validate:"omitempty,max=500"`
+	AvatarURL   *string `json:"avatar_url" validate:"omitempty,max=500"`
+	IsPrivate   *bool   `json:"is_private"`
+}
+
+// ChangePasswordInput represents input for changing the current user's password
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// UserLoginInput represents input for user login.
+// Session requests a browser session cookie instead of tokens.
 type UserLoginInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Session  bool   `json:"session"`
+}
+
+// RefreshTokenInput represents input for refreshing an access token
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutInput represents optional input for logging out
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// VerifyEmailInput represents input for verifying an email address
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ForgotPasswordInput represents input for requesting a password reset
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
 }
 
-// UserResponse represents a user in responses
+// ResetPasswordInput represents input for resetting a password
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// UserResponse represents a user in responses. Fields the viewer may not
+// see are omitted; see UserProjection.
 type UserResponse struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int64     `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email,omitempty"`
+	Roles       []string  `json:"roles,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	DisplayName *string   `json:"display_name,omitempty"`
+	Bio         *string   `json:"bio,omitempty"`
+	AvatarURL   *string   `json:"avatar_url,omitempty"`
+	IsPrivate   bool      `json:"is_private"`
+
+	// Follower counts, only included on profiles
+	FollowerCount  *int `json:"follower_count,omitempty"`
+	FollowingCount *int `json:"following_count,omitempty"`
+
+	// EmailVerifiedAt is omitted while the email address is unverified
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// DeleteAccountInput represents input for deleting the current user's account
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountDeletionResponse represents a scheduled account deletion
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// AccountExport represents a copy of all of a user's data
+type AccountExport struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	User       UserResponse   `json:"user"`
+	Posts      []PostResponse `json:"posts"`
+}
+
+// UserFilter represents filters for user queries
+type UserFilter struct {
+	// Search matches part of the username or email address
+	Search *string `json:"search,omitempty"`
+
+	// UsernamePrefix matches the start of the username, case-insensitively
+	UsernamePrefix *string `json:"username_prefix,omitempty"`
+	IsActive       *bool   `json:"is_active,omitempty"`
+	Role           *string `json:"role,omitempty"`
+}
+
+// AdminUserResponse represents a user in admin responses
+type AdminUserResponse struct {
+	ID              int64      `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	IsActive        bool       `json:"is_active"`
+	Roles           []string   `json:"roles"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // TokenResponse represents an authentication token response
 type TokenResponse struct {
-	Token     string       `json:"token"`
+	Token                 string       `json:"token"`
+	User                  UserResponse `json:"user"`
+	ExpiresAt             time.Time    `json:"expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+}
+
+// SessionLoginResponse represents a newly started browser session. The
+// session itself is only carried in an HttpOnly cookie.
+type SessionLoginResponse struct {
 	User      UserResponse `json:"user"`
+	CSRFToken string       `json:"csrf_token"`
 	ExpiresAt time.Time    `json:"expires_at"`
 }
+
+// SessionResponse represents a login session in responses
+type SessionResponse struct {
+	ID         int64      `json:"id"`
+	Kind       string     `json:"kind"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	Current    bool       `json:"current"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// MFACodeInput represents input carrying a two-factor code
+type MFACodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFAVerifyInput represents input for completing a two-factor login.
+// Code is either a TOTP code or an unused recovery code.
+type MFAVerifyInput struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+	Session  bool   `json:"session"`
+}
+
+// MFADisableInput represents input for disabling two-factor authentication
+type MFADisableInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFAEnrollmentResponse represents a pending TOTP enrollment
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse represents a newly issued set of recovery codes.
+// The codes are only ever shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeResponse is returned by login when a second factor is required
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey represents a personal API key
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the API key has expired
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// APIKeyStore defines the interface for API key operations
+type APIKeyStore interface {
+	// Create creates a new API key
+	Create(ctx context.Context, key *APIKey) error
+
+	// GetByHash retrieves an API key by the hash of its value
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+
+	// ListByUser retrieves all API keys belonging to a user
+	ListByUser(ctx context.Context, userID int64) ([]*APIKey, error)
+
+	// Delete revokes an API key belonging to a user
+	Delete(ctx context.Context, id, userID int64) error
+
+	// TouchLastUsed records that an API key was just used
+	TouchLastUsed(ctx context.Context, id int64) error
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Audit event names
+const (
+	AuditLoginLocked         = "login.locked"
+	AuditUserDeactivated     = "user.deactivated"
+	AuditUserReactivated     = "user.reactivated"
+	AuditPasswordResetForced = "user.password_reset_forced"
+	AuditDeletionScheduled   = "user.deletion_scheduled"
+	AuditDeletionCancelled   = "user.deletion_cancelled"
+	AuditAccountDeleted      = "user.deleted"
+	AuditDataExported        = "user.data_exported"
+)
+
+// AuditEvent represents a security-relevant event
+type AuditEvent struct {
+	ID        int64                  `json:"id"`
+	UserID    *int64                 `json:"user_id,omitempty"`
+	Event     string                 `json:"event"`
+	IP        string                 `json:"ip"`
+	Details   map[string]interface{} `json:"details"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AuditStore defines the interface for audit log operations
+type AuditStore interface {
+	// Create records a new audit event
+	Create(ctx context.Context, event *AuditEvent) error
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"social-api/internal/model"
+)
+
+// Comment represents a comment on a post, or a reply to another comment
+type Comment struct {
+	ID        int64     `json:"id"`
+	PostID    int64     `json:"post_id"`
+	ParentID  *int64    `json:"parent_id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// ReplyCount is the number of direct replies to the comment
+	ReplyCount int `json:"reply_count"`
+
+	// Depth is the comment's level below the top of the listed thread, only
+	// set by ListThreads
+	Depth int `json:"depth"`
+
+	// User is the author, loaded with the comment
+	User *User `json:"user,omitempty"`
+}
+
+// CommentStore defines the interface for comment operations
+type CommentStore interface {
+	// Create creates a new comment
+	Create(ctx context.Context, comment *Comment) error
+
+	// GetByID retrieves a comment by ID
+	GetByID(ctx context.Context, id int64) (*Comment, error)
+
+	// Update updates a comment's content
+	Update(ctx context.Context, comment *Comment) error
+
+	// Delete deletes a comment and all of its replies
+	Delete(ctx context.Context, id int64) error
+
+	// ListThreads retrieves a page of a post's top-level comments, or of the
+	// direct replies to parentID if it is set, each followed by its replies
+	// down to maxDepth levels below it. Comments are returned in thread order,
+	// and the total count is of the paginated comments only.
+	ListThreads(ctx context.Context, postID int64, parentID *int64, maxDepth int, pagination model.Pagination) ([]*Comment, int, error)
+}
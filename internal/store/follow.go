@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"social-api/internal/model"
+)
+
+// Follow statuses
+const (
+	// FollowPending is a follow request awaiting the followed user's approval
+	FollowPending = "pending"
+
+	// FollowAccepted is an established follow
+	FollowAccepted = "accepted"
+)
+
+// Follow represents a user following another user
+type Follow struct {
+	FollowerID int64     `json:"follower_id"`
+	FolloweeID int64     `json:"followee_id"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// User is the user on the other side of the follow, loaded when listing
+	// followers or followed users
+	User *User `json:"user,omitempty"`
+}
+
+// FollowStore defines the interface for follow graph operations
+type FollowStore interface {
+	// Create creates a follow with the given status. If the follower already
+	// follows or requested to follow the user, the existing follow is kept and
+	// its status and creation time are set on follow.
+	Create(ctx context.Context, follow *Follow) error
+
+	// Get retrieves the follow between two users, pending or accepted
+	Get(ctx context.Context, followerID, followeeID int64) (*Follow, error)
+
+	// Delete deletes the follow between two users, pending or accepted
+	Delete(ctx context.Context, followerID, followeeID int64) error
+
+	// Accept accepts a pending follow request
+	Accept(ctx context.Context, followerID, followeeID int64) error
+
+	// AcceptAll accepts all pending follow requests to a user and returns
+	// the IDs of the followers whose requests were accepted
+	AcceptAll(ctx context.Context, followeeID int64) ([]int64, error)
+
+	// ListFollowerIDs retrieves the IDs of all of a user's accepted followers
+	ListFollowerIDs(ctx context.Context, userID int64) ([]int64, error)
+
+	// ListFollowers retrieves a page of the follows of a user with the given
+	// status, newest first, with the followers
+	ListFollowers(ctx context.Context, userID int64, status string, pagination model.Pagination) ([]*Follow, int, error)
+
+	// ListFollowing retrieves a page of a user's accepted follows, newest
+	// first, with the followed users
+	ListFollowing(ctx context.Context, userID int64, pagination model.Pagination) ([]*Follow, int, error)
+
+	// Counts retrieves the number of accepted followers a user has and the
+	// number of users they follow
+	Counts(ctx context.Context, userID int64) (followers int, following int, err error)
+}
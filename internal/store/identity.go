@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// UserIdentity links a local user to an account at an external identity provider
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserIdentityStore defines the interface for linked identity operations
+type UserIdentityStore interface {
+	// Create links an external identity to a user
+	Create(ctx context.Context, identity *UserIdentity) error
+
+	// GetBySubject retrieves the identity with a provider's subject ID
+	GetBySubject(ctx context.Context, provider, subject string) (*UserIdentity, error)
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMFAEnabled is returned when enrolling a user who already has two-factor authentication enabled
+var ErrMFAEnabled = errors.New("two-factor authentication is already enabled")
+
+// UserMFA represents a user's TOTP enrollment
+type UserMFA struct {
+	UserID       int64      `json:"user_id"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// IsEnabled reports whether the enrollment has been confirmed
+func (m *UserMFA) IsEnabled() bool {
+	return m.EnabledAt != nil
+}
+
+// MFAStore defines the interface for two-factor authentication operations
+type MFAStore interface {
+	// Get retrieves a user's TOTP enrollment
+	Get(ctx context.Context, userID int64) (*UserMFA, error)
+
+	// SetPendingSecret starts or restarts enrollment with a new secret,
+	// returning ErrMFAEnabled if two-factor authentication is already enabled
+	SetPendingSecret(ctx context.Context, userID int64, secret string) error
+
+	// Enable confirms a pending enrollment, recording the time step of the
+	// confirming code, and replaces the user's recovery codes
+	Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error
+
+	// Disable removes a user's enrollment and recovery codes
+	Disable(ctx context.Context, userID int64) error
+
+	// UseStep records that a code from the given time step was used,
+	// returning ErrNotFound if that step or a later one was already used
+	UseStep(ctx context.Context, userID int64, step int64) error
+
+	// ReplaceRecoveryCodes replaces all of a user's recovery codes
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error
+
+	// UseRecoveryCode marks an unused recovery code as used, returning
+	// ErrNotFound if the code is unknown or has already been used
+	UseRecoveryCode(ctx context.Context, userID int64, hash string) error
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// OAuthClient represents a third-party application registered to use OAuth2
+type OAuthClient struct {
+	ID           int64     `json:"id"`
+	ClientID     string    `json:"client_id"`
+	SecretHash   string    `json:"-"`
+	OwnerID      int64     `json:"owner_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IsConfidential reports whether the client authenticates with a secret
+func (c *OAuthClient) IsConfidential() bool {
+	return c.SecretHash != ""
+}
+
+// HasRedirectURI reports whether a redirect URI is registered for the
+// client. URIs are compared exactly.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorizationCode represents an authorization code granted by a user to a client
+type OAuthAuthorizationCode struct {
+	ID            int64      `json:"id"`
+	CodeHash      string     `json:"-"`
+	ClientID      int64      `json:"client_id"`
+	UserID        int64      `json:"user_id"`
+	RedirectURI   string     `json:"redirect_uri"`
+	Scopes        []string   `json:"scopes"`
+	CodeChallenge string     `json:"-"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// OAuthStore defines the interface for OAuth2 client and authorization code operations
+type OAuthStore interface {
+	// CreateClient registers a new client
+	CreateClient(ctx context.Context, client *OAuthClient) error
+
+	// GetClient retrieves a client by its public client ID
+	GetClient(ctx context.Context, clientID string) (*OAuthClient, error)
+
+	// ListClientsByOwner retrieves all clients registered by a user
+	ListClientsByOwner(ctx context.Context, ownerID int64) ([]*OAuthClient, error)
+
+	// DeleteClient deletes a client registered by a user, along with its
+	// authorization codes and refresh tokens
+	DeleteClient(ctx context.Context, id, ownerID int64) error
+
+	// CreateCode stores a new authorization code
+	CreateCode(ctx context.Context, code *OAuthAuthorizationCode) error
+
+	// ConsumeCode marks an unused, unexpired authorization code as used and
+	// returns it, or returns ErrNotFound
+	ConsumeCode(ctx context.Context, hash string) (*OAuthAuthorizationCode, error)
+}
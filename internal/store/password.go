@@ -0,0 +1,200 @@
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+)
+
+// argon2id salt and key lengths in bytes
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// argon2idPrefix starts every argon2id hash in PHC string format
+var argon2idPrefix = []byte("$argon2id$")
+
+// ErrUnknownPasswordHash is returned for a stored hash in an unrecognised format
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordParams selects the algorithm and parameters for new password hashes.
+// Hashes are self-describing: bcrypt hashes carry their cost and argon2id
+// hashes use the PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$key),
+// so hashes made with older parameters keep verifying.
+type PasswordParams struct {
+	Algorithm string
+
+	// BcryptCost is the bcrypt work factor
+	BcryptCost int
+
+	// Argon2Memory is the argon2id memory cost in KiB
+	Argon2Memory uint32
+
+	// Argon2Iterations is the argon2id time cost
+	Argon2Iterations uint32
+
+	// Argon2Parallelism is the number of argon2id lanes
+	Argon2Parallelism uint8
+}
+
+// DefaultPasswordParams are the parameters used until SetPasswordParams is called
+var DefaultPasswordParams = PasswordParams{
+	Algorithm:         PasswordBcrypt,
+	BcryptCost:        bcrypt.DefaultCost,
+	Argon2Memory:      64 * 1024,
+	Argon2Iterations:  3,
+	Argon2Parallelism: 2,
+}
+
+// passwordParams are the parameters for new password hashes
+var passwordParams = DefaultPasswordParams
+
+// SetPasswordParams sets the algorithm and parameters for new password hashes.
+// It should be called once at startup.
+func SetPasswordParams(params PasswordParams) error {
+	switch params.Algorithm {
+	case PasswordBcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case PasswordArgon2id:
+		if params.Argon2Memory < 8*uint32(params.Argon2Parallelism) || params.Argon2Iterations < 1 || params.Argon2Parallelism < 1 {
+			return errors.New("invalid argon2id parameters")
+		}
+	default:
+		return fmt.Errorf("unknown password hash algorithm: %q", params.Algorithm)
+	}
+
+	passwordParams = params
+	return nil
+}
+
+// Password is a wrapper for user passwords
+type Password struct {
+	Plaintext *string `json:"-"` // Stores the plaintext password temporarily
+	Hash      []byte  `json:"-"` // Stores the hashed password
+}
+
+// Set sets a password by hashing the plaintext with the current parameters
+func (p *Password) Set(plaintext string) error {
+	var hash []byte
+	var err error
+	switch passwordParams.Algorithm {
+	case PasswordArgon2id:
+		hash, err = hashArgon2id(plaintext, passwordParams)
+	default:
+		hash, err = bcrypt.GenerateFromPassword([]byte(plaintext), passwordParams.BcryptCost)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.Plaintext = &plaintext
+	p.Hash = hash
+	return nil
+}
+
+// Matches checks if a plaintext password matches the hash. It also reports
+// whether the hash was made with an algorithm or parameters other than the
+// current ones, in which case the caller should Set the password again and
+// save the new hash while it has the plaintext.
+func (p *Password) Matches(plaintext string) (match bool, outdated bool, err error) {
+	if bytes.HasPrefix(p.Hash, argon2idPrefix) {
+		return matchArgon2id(p.Hash, plaintext)
+	}
+
+	err = bcrypt.CompareHashAndPassword(p.Hash, []byte(plaintext))
+	if err != nil {
+		switch {
+		case err == bcrypt.ErrMismatchedHashAndPassword:
+			return false, false, nil
+		default:
+			return false, false, err
+		}
+	}
+
+	// Check whether the hash uses the current algorithm and cost
+	cost, err := bcrypt.Cost(p.Hash)
+	if err != nil {
+		return false, false, err
+	}
+	outdated = passwordParams.Algorithm != PasswordBcrypt || cost != passwordParams.BcryptCost
+
+	return true, outdated, nil
+}
+
+// hashArgon2id hashes a password with argon2id and a random salt
+func hashArgon2id(plaintext string, params PasswordParams) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(plaintext), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, argon2KeyLength)
+
+	hash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Argon2Memory,
+		params.Argon2Iterations,
+		params.Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+	return []byte(hash), nil
+}
+
+// matchArgon2id checks a password against an argon2id hash in PHC string format
+func matchArgon2id(hash []byte, plaintext string) (bool, bool, error) {
+	// Parse $argon2id$v=19$m=65536,t=3,p=2$salt$key
+	parts := bytes.Split(hash, []byte("$"))
+	if len(parts) != 6 {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(string(parts[2]), "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	var params PasswordParams
+	params.Algorithm = PasswordArgon2id
+	if _, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Iterations, &params.Argon2Parallelism); err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(string(parts[4]))
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(string(parts[5]))
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+
+	// Recompute the key with the hash's own parameters
+	candidate := argon2.IDKey([]byte(plaintext), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return false, false, nil
+	}
+
+	// Check whether the hash uses the current algorithm and parameters
+	outdated := passwordParams.Algorithm != PasswordArgon2id ||
+		params.Argon2Memory != passwordParams.Argon2Memory ||
+		params.Argon2Iterations != passwordParams.Argon2Iterations ||
+		params.Argon2Parallelism != passwordParams.Argon2Parallelism
+
+	return true, outdated, nil
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// PasswordReset represents a one-time password reset token
+type PasswordReset struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the reset token has expired
+func (p *PasswordReset) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// PasswordResetStore defines the interface for password reset operations
+type PasswordResetStore interface {
+	// Create creates a new password reset token
+	Create(ctx context.Context, reset *PasswordReset) error
+
+	// GetByHash retrieves a password reset by the hash of its token
+	GetByHash(ctx context.Context, hash string) (*PasswordReset, error)
+
+	// MarkUsed marks an unused reset token as used, returning ErrNotFound
+	// if it has already been used
+	MarkUsed(ctx context.Context, id int64) error
+
+	// InvalidateForUser marks every outstanding reset token of a user as used
+	InvalidateForUser(ctx context.Context, userID int64) error
+}
@@ -7,24 +7,54 @@ import (
 	"social-api/internal/model"
 )
 
+// Post visibility levels
+const (
+	// VisibilityPublic posts are visible to and listed for every user
+	VisibilityPublic = "public"
+
+	// VisibilityUnlisted posts are visible to every user, but only listed for
+	// their author
+	VisibilityUnlisted = "unlisted"
+
+	// VisibilityFollowers posts are only visible to their author and the
+	// author's accepted followers
+	VisibilityFollowers = "followers"
+
+	// VisibilityPrivate posts are only visible to their author
+	VisibilityPrivate = "private"
+)
+
 // Post represents a post in the system
 type Post struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	UserID    int64     `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	User      *User     `json:"-"`
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	UserID     int64     `json:"user_id"`
+	Visibility string    `json:"visibility"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// ReactionCounts is the number of reactions of each kind, loaded with the post
+	ReactionCounts map[string]int `json:"reaction_counts"`
+
+	// User is the author, loaded with the post
+	User *User `json:"user,omitempty"`
+}
+
+// IsVisibleToAll reports whether every user may see the post
+func (p *Post) IsVisibleToAll() bool {
+	return p.Visibility == VisibilityPublic || p.Visibility == VisibilityUnlisted
 }
 
-// PostStore defines the interface for post operations
+// PostStore defines the interface for post operations. Posts are only
+// retrieved if the viewer may see them; a nil viewer only sees posts visible
+// to all, and viewers with PermPostsReadAny see every post.
 type PostStore interface {
 	// Create creates a new post
 	Create(ctx context.Context, post *Post) error
 
 	// GetByID retrieves a post by ID
-	GetByID(ctx context.Context, id int64) (*Post, error)
+	GetByID(ctx context.Context, viewer *User, id int64) (*Post, error)
 
 	// Update updates a post
 	Update(ctx context.Context, post *Post) error
@@ -32,6 +62,17 @@ type PostStore interface {
 	// Delete deletes a post
 	Delete(ctx context.Context, id int64) error
 
-	// List retrieves a list of posts
-	List(ctx context.Context, pagination model.Pagination, filter model.PostFilter) ([]*Post, int, error)
+	// List retrieves a list of posts. Unlisted posts are only listed for
+	// their author.
+	List(ctx context.Context, viewer *User, pagination model.Pagination, filter model.PostFilter) ([]*Post, int, error)
+
+	// ListFeed retrieves up to limit posts by a user and the users they follow,
+	// newest first, leaving out other users' private and unlisted posts. If
+	// authorIDs is not nil, only posts by those authors are included. If
+	// before is set, only posts with a lower ID are included.
+	ListFeed(ctx context.Context, userID int64, authorIDs []int64, before int64, limit int) ([]*Post, error)
+
+	// ListByIDs retrieves the posts with the given IDs, newest first, leaving
+	// out any that no longer exist or are not listed for the viewer
+	ListByIDs(ctx context.Context, viewer *User, ids []int64) ([]*Post, error)
 }
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"social-api/internal/store"
+)
+
+// APIKeyStore implements store.APIKeyStore using PostgreSQL
+type APIKeyStore struct {
+	db *sql.DB
+}
+
+// NewAPIKeyStore creates a new PostgreSQL API key store
+func NewAPIKeyStore(db *sql.DB) *APIKeyStore {
+	return &APIKeyStore{
+		db: db,
+	}
+}
+
+// Create creates a new API key
+func (s *APIKeyStore) Create(ctx context.Context, key *store.APIKey) error {
+	// SQL query to insert a new API key
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.ExpiresAt,
+	).Scan(
+		&key.ID,
+		&key.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash retrieves an API key by its hash
+func (s *APIKeyStore) GetByHash(ctx context.Context, hash string) (*store.APIKey, error) {
+	// SQL query to get an API key by hash
+	query := `
+		SELECT id, user_id, name, prefix, key_hash, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Key to store the result
+	var key store.APIKey
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, hash).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// ListByUser retrieves all API keys belonging to a user
+func (s *APIKeyStore) ListByUser(ctx context.Context, userID int64) ([]*store.APIKey, error) {
+	// SQL query to list a user's API keys
+	query := `
+		SELECT id, user_id, name, prefix, key_hash, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for keys
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	keys := []*store.APIKey{}
+	for rows.Next() {
+		var key store.APIKey
+
+		err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, &key)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Delete revokes an API key belonging to a user
+func (s *APIKeyStore) Delete(ctx context.Context, id, userID int64) error {
+	// SQL query to delete an API key
+	query := `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records that an API key was just used
+func (s *APIKeyStore) TouchLastUsed(ctx context.Context, id int64) error {
+	// SQL query to update last_used_at, at most once a minute per key
+	// so that busy keys do not cost a write on every request
+	query := `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"social-api/internal/store"
+)
+
+// AuditStore implements store.AuditStore using PostgreSQL
+type AuditStore struct {
+	db *sql.DB
+}
+
+// NewAuditStore creates a new PostgreSQL audit store
+func NewAuditStore(db *sql.DB) *AuditStore {
+	return &AuditStore{
+		db: db,
+	}
+}
+
+// Create records a new audit event
+func (s *AuditStore) Create(ctx context.Context, event *store.AuditEvent) error {
+	// SQL query to insert a new audit event
+	query := `
+		INSERT INTO audit_events (user_id, event, ip, details)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	// Encode details as JSON
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+	if event.Details == nil {
+		details = []byte("{}")
+	}
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err = s.db.QueryRowContext(
+		ctx,
+		query,
+		event.UserID,
+		event.Event,
+		event.IP,
+		details,
+	).Scan(
+		&event.ID,
+		&event.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// commentSortColumns maps the sort_by values accepted for comments to columns
+var commentSortColumns = map[string]string{
+	"id":         "c.id",
+	"created_at": "c.created_at",
+}
+
+// CommentStore implements store.CommentStore using PostgreSQL
+type CommentStore struct {
+	db *sql.DB
+}
+
+// NewCommentStore creates a new PostgreSQL comment store
+func NewCommentStore(db *sql.DB) *CommentStore {
+	return &CommentStore{
+		db: db,
+	}
+}
+
+// Create creates a new comment
+func (s *CommentStore) Create(ctx context.Context, comment *store.Comment) error {
+	// SQL query to insert a new comment
+	query := `
+		INSERT INTO comments (post_id, parent_id, user_id, content)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		comment.PostID,
+		comment.ParentID,
+		comment.UserID,
+		comment.Content,
+	).Scan(
+		&comment.ID,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByID retrieves a comment by ID
+func (s *CommentStore) GetByID(ctx context.Context, id int64) (*store.Comment, error) {
+	// SQL query to get a comment by ID with user information
+	query := `
+		SELECT
+			c.id, c.post_id, c.parent_id, c.user_id, c.content, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id),
+			u.id, u.username, u.email, u.is_active, u.created_at,
+			u.display_name, u.bio, u.avatar_url, u.is_private
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.id = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	comment, err := scanComment(s.db.QueryRowContext(ctx, query, id), false)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// Update updates a comment's content
+func (s *CommentStore) Update(ctx context.Context, comment *store.Comment) error {
+	// SQL query to update a comment
+	query := `
+		UPDATE comments
+		SET content = $1
+		WHERE id = $2
+		RETURNING updated_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, comment.Content, comment.ID).Scan(&comment.UpdatedAt)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete deletes a comment; its replies are removed by ON DELETE CASCADE
+func (s *CommentStore) Delete(ctx context.Context, id int64) error {
+	// SQL query to delete a comment
+	query := `DELETE FROM comments WHERE id = $1`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListThreads retrieves a page of comments, each followed by its replies
+func (s *CommentStore) ListThreads(ctx context.Context, postID int64, parentID *int64, maxDepth int, pagination model.Pagination) ([]*store.Comment, int, error) {
+	// Paginate either the post's top-level comments or one comment's replies
+	condition := "c.post_id = $1 AND c.parent_id IS NULL"
+	args := []interface{}{postID}
+	if parentID != nil {
+		condition = "c.post_id = $1 AND c.parent_id = $2"
+		args = append(args, *parentID)
+	}
+
+	// Only allow sorting by known columns
+	sortColumn, ok := commentSortColumns[pagination.SortBy]
+	if !ok {
+		sortColumn = commentSortColumns[model.DefaultSortBy]
+	}
+	order := fmt.Sprintf("%s %s, c.id %s", sortColumn, pagination.Sort, pagination.Sort)
+
+	// Count query for total records
+	countQuery := `SELECT COUNT(*) FROM comments c WHERE ` + condition
+
+	// SQL query to select a page of comments, then walk down their replies.
+	// Each comment's path starts with its page position, followed by the IDs
+	// leading to it, so that ordering by path gives depth-first thread order
+	// with replies oldest first.
+	query := fmt.Sprintf(`
+		WITH RECURSIVE page AS (
+			SELECT c.id, ROW_NUMBER() OVER (ORDER BY %[1]s) AS position
+			FROM comments c
+			WHERE %[2]s
+			ORDER BY %[1]s
+			LIMIT $%[3]d OFFSET $%[4]d
+		), thread AS (
+			SELECT p.id, 0 AS depth, ARRAY[p.position, p.id::BIGINT] AS path
+			FROM page p
+			UNION ALL
+			SELECT c.id, t.depth + 1, t.path || c.id::BIGINT
+			FROM comments c
+			JOIN thread t ON c.parent_id = t.id
+			WHERE t.depth < $%[5]d
+		)
+		SELECT
+			c.id, c.post_id, c.parent_id, c.user_id, c.content, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id),
+			u.id, u.username, u.email, u.is_active, u.created_at,
+			u.display_name, u.bio, u.avatar_url, u.is_private,
+			t.depth
+		FROM thread t
+		JOIN comments c ON c.id = t.id
+		JOIN users u ON c.user_id = u.id
+		ORDER BY t.path
+	`, order, condition, len(args)+1, len(args)+2, len(args)+3)
+	queryArgs := append(args, pagination.PageSize, pagination.GetOffset(), maxDepth)
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for total count
+	var totalCount int
+	err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Query for comments
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	comments := []*store.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows, true)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, comment)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return comments, totalCount, nil
+}
+
+// scanComment scans a comment row with its reply count and author, and its
+// depth if withDepth is set
+func scanComment(row scanner, withDepth bool) (*store.Comment, error) {
+	// Comment and user to store the result
+	var comment store.Comment
+	var user store.User
+
+	dest := []interface{}{
+		&comment.ID,
+		&comment.PostID,
+		&comment.ParentID,
+		&comment.UserID,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.ReplyCount,
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
+		&user.IsPrivate,
+	}
+	if withDepth {
+		dest = append(dest, &comment.Depth)
+	}
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set user
+	comment.User = &user
+
+	return &comment, nil
+}
@@ -0,0 +1,315 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// FollowStore implements store.FollowStore using PostgreSQL
+type FollowStore struct {
+	db *sql.DB
+}
+
+// NewFollowStore creates a new PostgreSQL follow store
+func NewFollowStore(db *sql.DB) *FollowStore {
+	return &FollowStore{
+		db: db,
+	}
+}
+
+// Create creates a follow, keeping any existing follow between the users
+func (s *FollowStore) Create(ctx context.Context, follow *store.Follow) error {
+	// SQL query to insert a follow; the no-op update returns the existing row
+	// on conflict
+	query := `
+		INSERT INTO follows (follower_id, followee_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (follower_id, followee_id) DO UPDATE SET status = follows.status
+		RETURNING status, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		follow.FollowerID,
+		follow.FolloweeID,
+		follow.Status,
+	).Scan(
+		&follow.Status,
+		&follow.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get retrieves the follow between two users
+func (s *FollowStore) Get(ctx context.Context, followerID, followeeID int64) (*store.Follow, error) {
+	// SQL query to get a follow
+	query := `
+		SELECT follower_id, followee_id, status, created_at
+		FROM follows
+		WHERE follower_id = $1 AND followee_id = $2
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	var follow store.Follow
+	err := s.db.QueryRowContext(ctx, query, followerID, followeeID).Scan(
+		&follow.FollowerID,
+		&follow.FolloweeID,
+		&follow.Status,
+		&follow.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &follow, nil
+}
+
+// Delete deletes the follow between two users
+func (s *FollowStore) Delete(ctx context.Context, followerID, followeeID int64) error {
+	// SQL query to delete a follow
+	query := `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// Accept accepts a pending follow request
+func (s *FollowStore) Accept(ctx context.Context, followerID, followeeID int64) error {
+	// SQL query to accept a follow request
+	query := `
+		UPDATE follows
+		SET status = $3
+		WHERE follower_id = $1 AND followee_id = $2 AND status = $4
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, followerID, followeeID, store.FollowAccepted, store.FollowPending)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// AcceptAll accepts all pending follow requests to a user
+func (s *FollowStore) AcceptAll(ctx context.Context, followeeID int64) ([]int64, error) {
+	// SQL query to accept all follow requests
+	query := `
+		UPDATE follows SET status = $2
+		WHERE followee_id = $1 AND status = $3
+		RETURNING follower_id
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queryIDs(ctx, query, followeeID, store.FollowAccepted, store.FollowPending)
+}
+
+// ListFollowerIDs retrieves the IDs of a user's accepted followers
+func (s *FollowStore) ListFollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	// SQL query to select the followers
+	query := `SELECT follower_id FROM follows WHERE followee_id = $1 AND status = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queryIDs(ctx, query, userID, store.FollowAccepted)
+}
+
+// queryIDs runs a query selecting a single ID column and scans the IDs
+func (s *FollowStore) queryIDs(ctx context.Context, query string, args ...interface{}) ([]int64, error) {
+	// Execute query
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ListFollowers retrieves a page of the follows of a user with the given status
+func (s *FollowStore) ListFollowers(ctx context.Context, userID int64, status string, pagination model.Pagination) ([]*store.Follow, int, error) {
+	return s.list(ctx, "f.followee_id", "f.follower_id", userID, status, pagination)
+}
+
+// ListFollowing retrieves a page of a user's accepted follows
+func (s *FollowStore) ListFollowing(ctx context.Context, userID int64, pagination model.Pagination) ([]*store.Follow, int, error) {
+	return s.list(ctx, "f.follower_id", "f.followee_id", userID, store.FollowAccepted, pagination)
+}
+
+// list retrieves a page of follows where userColumn is the user, with the
+// users in otherColumn
+func (s *FollowStore) list(ctx context.Context, userColumn, otherColumn string, userID int64, status string, pagination model.Pagination) ([]*store.Follow, int, error) {
+	// Count query for total records
+	countQuery := `SELECT COUNT(*) FROM follows f WHERE ` + userColumn + ` = $1 AND f.status = $2`
+
+	// SQL query to select a page of follows
+	query := `
+		SELECT
+			f.follower_id, f.followee_id, f.status, f.created_at,
+			u.id, u.username, u.email, u.is_active, u.created_at,
+			u.display_name, u.bio, u.avatar_url, u.is_private
+		FROM follows f
+		JOIN users u ON ` + otherColumn + ` = u.id
+		WHERE ` + userColumn + ` = $1 AND f.status = $2
+		ORDER BY f.created_at DESC, ` + otherColumn + ` DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for total count
+	var totalCount int
+	err := s.db.QueryRowContext(ctx, countQuery, userID, status).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Query for follows
+	rows, err := s.db.QueryContext(ctx, query, userID, status, pagination.PageSize, pagination.GetOffset())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	follows := []*store.Follow{}
+	for rows.Next() {
+		var follow store.Follow
+		var user store.User
+
+		err := rows.Scan(
+			&follow.FollowerID,
+			&follow.FolloweeID,
+			&follow.Status,
+			&follow.CreatedAt,
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.IsActive,
+			&user.CreatedAt,
+			&user.DisplayName,
+			&user.Bio,
+			&user.AvatarURL,
+			&user.IsPrivate,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Set user
+		follow.User = &user
+		follows = append(follows, &follow)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return follows, totalCount, nil
+}
+
+// Counts retrieves a user's accepted follower and following counts
+func (s *FollowStore) Counts(ctx context.Context, userID int64) (int, int, error) {
+	// SQL query to count both sides of the user's follows
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM follows WHERE followee_id = $1 AND status = $2),
+			(SELECT COUNT(*) FROM follows WHERE follower_id = $1 AND status = $2)
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	var followers, following int
+	err := s.db.QueryRowContext(ctx, query, userID, store.FollowAccepted).Scan(&followers, &following)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return followers, following, nil
+}
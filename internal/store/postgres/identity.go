@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"social-api/internal/store"
+)
+
+// UserIdentityStore implements store.UserIdentityStore using PostgreSQL
+type UserIdentityStore struct {
+	db *sql.DB
+}
+
+// NewUserIdentityStore creates a new PostgreSQL linked identity store
+func NewUserIdentityStore(db *sql.DB) *UserIdentityStore {
+	return &UserIdentityStore{
+		db: db,
+	}
+}
+
+// Create links an external identity to a user
+func (s *UserIdentityStore) Create(ctx context.Context, identity *store.UserIdentity) error {
+	// SQL query to insert a new identity
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+	).Scan(
+		&identity.ID,
+		&identity.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBySubject retrieves the identity with a provider's subject ID
+func (s *UserIdentityStore) GetBySubject(ctx context.Context, provider, subject string) (*store.UserIdentity, error) {
+	// SQL query to get an identity by provider and subject
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Identity to store the result
+	var identity store.UserIdentity
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"social-api/internal/db"
+	"social-api/internal/store"
+)
+
+// MFAStore implements store.MFAStore using PostgreSQL
+type MFAStore struct {
+	db *sql.DB
+}
+
+// NewMFAStore creates a new PostgreSQL two-factor authentication store
+func NewMFAStore(db *sql.DB) *MFAStore {
+	return &MFAStore{
+		db: db,
+	}
+}
+
+// Get retrieves a user's TOTP enrollment
+func (s *MFAStore) Get(ctx context.Context, userID int64) (*store.UserMFA, error) {
+	// SQL query to get an enrollment by user ID
+	query := `
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Enrollment to store the result
+	var mfa store.UserMFA
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.EnabledAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+// SetPendingSecret starts or restarts enrollment with a new secret
+func (s *MFAStore) SetPendingSecret(ctx context.Context, userID int64, secret string) error {
+	// SQL query to upsert the enrollment, leaving confirmed enrollments untouched
+	query := `
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrMFAEnabled
+	}
+
+	return nil
+}
+
+// Enable confirms a pending enrollment and replaces the user's recovery codes
+func (s *MFAStore) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return db.ExecuteTx(ctx, s.db, func(tx *db.Transaction) error {
+		// Confirm the enrollment only if it is still pending
+		result, err := tx.ExecContext(ctx, `
+			UPDATE user_mfa
+			SET enabled_at = NOW(), last_used_step = $2
+			WHERE user_id = $1 AND enabled_at IS NULL
+		`, userID, step)
+		if err != nil {
+			return err
+		}
+
+		// Check if any rows were affected
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return store.ErrNotFound
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	})
+}
+
+// Disable removes a user's enrollment and recovery codes
+func (s *MFAStore) Disable(ctx context.Context, userID int64) error {
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return db.ExecuteTx(ctx, s.db, func(tx *db.Transaction) error {
+		// Delete recovery codes
+		_, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		// Delete the enrollment
+		result, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		// Check if any rows were affected
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return store.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// UseStep records that a code from the given time step was used
+func (s *MFAStore) UseStep(ctx context.Context, userID int64, step int64) error {
+	// SQL query to advance the last used step, rejecting replays
+	query := `
+		UPDATE user_mfa
+		SET last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces all of a user's recovery codes
+func (s *MFAStore) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error {
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return db.ExecuteTx(ctx, s.db, func(tx *db.Transaction) error {
+		return replaceRecoveryCodes(ctx, tx, userID, hashes)
+	})
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and inserts new ones within a transaction
+func replaceRecoveryCodes(ctx context.Context, tx *db.Transaction, userID int64, hashes []string) error {
+	// Delete existing codes
+	_, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	// Insert new codes
+	for _, hash := range hashes {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO mfa_recovery_codes (user_id, code_hash)
+			VALUES ($1, $2)
+		`, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used
+func (s *MFAStore) UseRecoveryCode(ctx context.Context, userID int64, hash string) error {
+	// SQL query to mark the code as used only if it has not been used yet
+	query := `
+		UPDATE mfa_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, userID, hash)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
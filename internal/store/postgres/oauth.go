@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"social-api/internal/store"
+)
+
+// OAuthStore implements store.OAuthStore using PostgreSQL
+type OAuthStore struct {
+	db *sql.DB
+}
+
+// NewOAuthStore creates a new PostgreSQL OAuth2 store
+func NewOAuthStore(db *sql.DB) *OAuthStore {
+	return &OAuthStore{
+		db: db,
+	}
+}
+
+// CreateClient registers a new client
+func (s *OAuthStore) CreateClient(ctx context.Context, client *store.OAuthClient) error {
+	// SQL query to insert a new client
+	query := `
+		INSERT INTO oauth_clients (client_id, secret_hash, owner_id, name, redirect_uris, scopes)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		client.ClientID,
+		client.SecretHash,
+		client.OwnerID,
+		client.Name,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.Scopes),
+	).Scan(
+		&client.ID,
+		&client.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetClient retrieves a client by its public client ID
+func (s *OAuthStore) GetClient(ctx context.Context, clientID string) (*store.OAuthClient, error) {
+	// SQL query to get a client by client ID
+	query := `
+		SELECT id, client_id, COALESCE(secret_hash, ''), owner_id, name, redirect_uris, scopes, created_at
+		FROM oauth_clients
+		WHERE client_id = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Client to store the result
+	var client store.OAuthClient
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, clientID).Scan(
+		&client.ID,
+		&client.ClientID,
+		&client.SecretHash,
+		&client.OwnerID,
+		&client.Name,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes),
+		&client.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// ListClientsByOwner retrieves all clients registered by a user
+func (s *OAuthStore) ListClientsByOwner(ctx context.Context, ownerID int64) ([]*store.OAuthClient, error) {
+	// SQL query to list a user's clients
+	query := `
+		SELECT id, client_id, COALESCE(secret_hash, ''), owner_id, name, redirect_uris, scopes, created_at
+		FROM oauth_clients
+		WHERE owner_id = $1
+		ORDER BY created_at DESC
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for clients
+	rows, err := s.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	clients := []*store.OAuthClient{}
+	for rows.Next() {
+		var client store.OAuthClient
+
+		err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.SecretHash,
+			&client.OwnerID,
+			&client.Name,
+			pq.Array(&client.RedirectURIs),
+			pq.Array(&client.Scopes),
+			&client.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, &client)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// DeleteClient deletes a client registered by a user. Its authorization
+// codes and refresh tokens are removed by cascade.
+func (s *OAuthStore) DeleteClient(ctx context.Context, id, ownerID int64) error {
+	// SQL query to delete a client
+	query := `DELETE FROM oauth_clients WHERE id = $1 AND owner_id = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id, ownerID)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateCode stores a new authorization code
+func (s *OAuthStore) CreateCode(ctx context.Context, code *store.OAuthAuthorizationCode) error {
+	// SQL query to insert a new authorization code
+	query := `
+		INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		pq.Array(code.Scopes),
+		code.CodeChallenge,
+		code.ExpiresAt,
+	).Scan(
+		&code.ID,
+		&code.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeCode marks an unused, unexpired authorization code as used and returns it
+func (s *OAuthStore) ConsumeCode(ctx context.Context, hash string) (*store.OAuthAuthorizationCode, error) {
+	// SQL query to use a code only if it is still valid, so that concurrent
+	// redemptions of the same code cannot both succeed
+	query := `
+		UPDATE oauth_authorization_codes
+		SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, used_at, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Code to store the result
+	var code store.OAuthAuthorizationCode
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, hash).Scan(
+		&code.ID,
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		pq.Array(&code.Scopes),
+		&code.CodeChallenge,
+		&code.ExpiresAt,
+		&code.UsedAt,
+		&code.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &code, nil
+}
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"social-api/internal/store"
+)
+
+// PasswordResetStore implements store.PasswordResetStore using PostgreSQL
+type PasswordResetStore struct {
+	db *sql.DB
+}
+
+// NewPasswordResetStore creates a new PostgreSQL password reset store
+func NewPasswordResetStore(db *sql.DB) *PasswordResetStore {
+	return &PasswordResetStore{
+		db: db,
+	}
+}
+
+// Create creates a new password reset token
+func (s *PasswordResetStore) Create(ctx context.Context, reset *store.PasswordReset) error {
+	// SQL query to insert a new password reset
+	query := `
+		INSERT INTO password_resets (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		reset.UserID,
+		reset.TokenHash,
+		reset.ExpiresAt,
+	).Scan(
+		&reset.ID,
+		&reset.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a password reset by its token hash
+func (s *PasswordResetStore) GetByHash(ctx context.Context, hash string) (*store.PasswordReset, error) {
+	// SQL query to get a password reset by hash
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_resets
+		WHERE token_hash = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Reset to store the result
+	var reset store.PasswordReset
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, hash).Scan(
+		&reset.ID,
+		&reset.UserID,
+		&reset.TokenHash,
+		&reset.ExpiresAt,
+		&reset.UsedAt,
+		&reset.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+// MarkUsed marks an unused reset token as used
+func (s *PasswordResetStore) MarkUsed(ctx context.Context, id int64) error {
+	// SQL query to mark the token as used only if it has not been used yet
+	query := `
+		UPDATE password_resets
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// InvalidateForUser marks every outstanding reset token of a user as used
+func (s *PasswordResetStore) InvalidateForUser(ctx context.Context, userID int64) error {
+	// SQL query to invalidate outstanding tokens
+	query := `
+		UPDATE password_resets
+		SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return err
+}
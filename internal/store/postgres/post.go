@@ -8,10 +8,30 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"social-api/internal/model"
 	"social-api/internal/store"
 )
 
+// postColumns is the column list selected for a post with its author and
+// reaction counts, from posts p joined with users u
+const postColumns = `
+	p.id, p.title, p.content, p.user_id, p.visibility, p.created_at, p.updated_at,
+	u.id, u.username, u.email, u.is_active, u.created_at,
+	u.display_name, u.bio, u.avatar_url, u.is_private,
+	ARRAY(SELECT rc.kind FROM post_reaction_counts rc WHERE rc.post_id = p.id ORDER BY rc.kind),
+	ARRAY(SELECT rc.count FROM post_reaction_counts rc WHERE rc.post_id = p.id ORDER BY rc.kind)
+`
+
+// postSortColumns maps the sort_by values accepted for posts to columns
+var postSortColumns = map[string]string{
+	"id":         "p.id",
+	"title":      "p.title",
+	"created_at": "p.created_at",
+	"updated_at": "p.updated_at",
+}
+
 // PostStore implements store.PostStore using PostgreSQL
 type PostStore struct {
 	db *sql.DB
@@ -28,8 +48,8 @@ func NewPostStore(db *sql.DB) *PostStore {
 func (s *PostStore) Create(ctx context.Context, post *store.Post) error {
 	// SQL query to insert a new post
 	query := `
-		INSERT INTO posts (title, content, user_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO posts (title, content, user_id, visibility)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -44,6 +64,7 @@ func (s *PostStore) Create(ctx context.Context, post *store.Post) error {
 		post.Title,
 		post.Content,
 		post.UserID,
+		post.Visibility,
 	).Scan(
 		&post.ID,
 		&post.CreatedAt,
@@ -58,40 +79,25 @@ func (s *PostStore) Create(ctx context.Context, post *store.Post) error {
 	return nil
 }
 
-// GetByID retrieves a post by ID
-func (s *PostStore) GetByID(ctx context.Context, id int64) (*store.Post, error) {
+// GetByID retrieves a post by ID if the viewer may see it
+func (s *PostStore) GetByID(ctx context.Context, viewer *store.User, id int64) (*store.Post, error) {
+	// Only match the post if the viewer may see it
+	visible, args := visibleClause(viewer, false, []interface{}{id})
+
 	// SQL query to get a post by ID with user information
 	query := `
-		SELECT 
-			p.id, p.title, p.content, p.user_id, p.created_at, p.updated_at,
-			u.id, u.username, u.email, u.is_active, u.created_at
+		SELECT ` + postColumns + `
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		WHERE p.id = $1
+		WHERE p.id = $1 AND ` + visible + `
 	`
 
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// Post and user to store the result
-	var post store.Post
-	var user store.User
-
 	// Execute query
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&post.ID,
-		&post.Title,
-		&post.Content,
-		&post.UserID,
-		&post.CreatedAt,
-		&post.UpdatedAt,
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.IsActive,
-		&user.CreatedAt,
-	)
+	post, err := scanPost(s.db.QueryRowContext(ctx, query, args...))
 
 	// Check for errors
 	if err != nil {
@@ -101,10 +107,7 @@ func (s *PostStore) GetByID(ctx context.Context, id int64) (*store.Post, error)
 		return nil, err
 	}
 
-	// Set user
-	post.User = &user
-
-	return &post, nil
+	return post, nil
 }
 
 // Update updates a post
@@ -112,8 +115,8 @@ func (s *PostStore) Update(ctx context.Context, post *store.Post) error {
 	// SQL query to update a post
 	query := `
 		UPDATE posts
-		SET title = $1, content = $2
-		WHERE id = $3 AND user_id = $4
+		SET title = $1, content = $2, visibility = $3
+		WHERE id = $4 AND user_id = $5
 		RETURNING updated_at
 	`
 
@@ -127,6 +130,7 @@ func (s *PostStore) Update(ctx context.Context, post *store.Post) error {
 		query,
 		post.Title,
 		post.Content,
+		post.Visibility,
 		post.ID,
 		post.UserID,
 	).Scan(&post.UpdatedAt)
@@ -170,13 +174,11 @@ func (s *PostStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-// List retrieves a list of posts
-func (s *PostStore) List(ctx context.Context, pagination model.Pagination, filter model.PostFilter) ([]*store.Post, int, error) {
+// List retrieves a list of the posts the viewer may see
+func (s *PostStore) List(ctx context.Context, viewer *store.User, pagination model.Pagination, filter model.PostFilter) ([]*store.Post, int, error) {
 	// Base query for listing posts
 	baseQuery := `
-		SELECT 
-			p.id, p.title, p.content, p.user_id, p.created_at, p.updated_at,
-			u.id, u.username, u.email, u.is_active, u.created_at
+		SELECT ` + postColumns + `
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 	`
@@ -186,15 +188,22 @@ func (s *PostStore) List(ctx context.Context, pagination model.Pagination, filte
 		SELECT COUNT(*) FROM posts p
 	`
 
-	// Build where clause
+	// Build where clause, only matching posts the viewer may see
 	whereClause, args := s.buildWhereClause(filter)
+	visible, args := visibleClause(viewer, true, args)
 	if whereClause != "" {
-		baseQuery += " WHERE " + whereClause
-		countQuery += " WHERE " + whereClause
+		whereClause += " AND "
 	}
-
-	// Add order by clause
-	baseQuery += fmt.Sprintf(" ORDER BY p.%s %s", pagination.SortBy, pagination.Sort)
+	whereClause += visible
+	baseQuery += " WHERE " + whereClause
+	countQuery += " WHERE " + whereClause
+
+	// Add order by clause, falling back to creation time for unknown columns
+	sortColumn, ok := postSortColumns[pagination.SortBy]
+	if !ok {
+		sortColumn = postSortColumns[model.DefaultSortBy]
+	}
+	baseQuery += fmt.Sprintf(" ORDER BY %s %s, p.id %s", sortColumn, pagination.Sort, pagination.Sort)
 
 	// Add pagination
 	baseQuery += " LIMIT $" + fmt.Sprint(len(args)+1) + " OFFSET $" + fmt.Sprint(len(args)+2)
@@ -221,29 +230,11 @@ func (s *PostStore) List(ctx context.Context, pagination model.Pagination, filte
 	// Process rows
 	posts := []*store.Post{}
 	for rows.Next() {
-		var post store.Post
-		var user store.User
-
-		err := rows.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.UserID,
-			&post.CreatedAt,
-			&post.UpdatedAt,
-			&user.ID,
-			&user.Username,
-			&user.Email,
-			&user.IsActive,
-			&user.CreatedAt,
-		)
+		post, err := scanPost(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-
-		// Set user
-		post.User = &user
-		posts = append(posts, &post)
+		posts = append(posts, post)
 	}
 
 	// Check for errors in row iteration
@@ -300,3 +291,173 @@ func (s *PostStore) buildWhereClause(filter model.PostFilter) (string, []interfa
 
 	return whereClause, args
 }
+
+// ListFeed retrieves up to limit posts by a user and the users they follow,
+// newest first
+func (s *PostStore) ListFeed(ctx context.Context, userID int64, authorIDs []int64, before int64, limit int) ([]*store.Post, error) {
+	// Leave out other users' private and unlisted posts, restrict to the
+	// given authors if any, and to posts older than the cursor
+	condition := "(p.visibility NOT IN ('private', 'unlisted') OR p.user_id = $1)"
+	args := []interface{}{userID, store.FollowAccepted, limit}
+	if authorIDs != nil {
+		args = append(args, pq.Array(authorIDs))
+		condition += fmt.Sprintf(" AND p.user_id = ANY($%d)", len(args))
+	}
+	if before > 0 {
+		args = append(args, before)
+		condition += fmt.Sprintf(" AND p.id < $%d", len(args))
+	}
+
+	// SQL query to select the newest posts by the user and the users they follow
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE (
+			p.user_id = $1 OR p.user_id IN (
+				SELECT f.followee_id FROM follows f
+				WHERE f.follower_id = $1 AND f.status = $2
+			)
+		) AND ` + condition + `
+		ORDER BY p.id DESC
+		LIMIT $3
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queryPosts(ctx, query, args...)
+}
+
+// ListByIDs retrieves the posts with the given IDs that still exist and are
+// listed for the viewer, newest first
+func (s *PostStore) ListByIDs(ctx context.Context, viewer *store.User, ids []int64) ([]*store.Post, error) {
+	if len(ids) == 0 {
+		return []*store.Post{}, nil
+	}
+
+	// Only match posts listed for the viewer
+	visible, args := visibleClause(viewer, true, []interface{}{pq.Array(ids)})
+
+	// SQL query to select posts by ID
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ANY($1) AND ` + visible + `
+		ORDER BY p.id DESC
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.queryPosts(ctx, query, args...)
+}
+
+// queryPosts runs a query selecting postColumns and scans the posts
+func (s *PostStore) queryPosts(ctx context.Context, query string, args ...interface{}) ([]*store.Post, error) {
+	// Execute query
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	posts := []*store.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// visibleClause builds a condition matching the posts a viewer may see,
+// appending its arguments to args. If listed is set, unlisted posts are only
+// matched for their author.
+func visibleClause(viewer *store.User, listed bool, args []interface{}) (string, []interface{}) {
+	if viewer != nil && viewer.HasPermission(store.PermPostsReadAny) {
+		return "TRUE", args
+	}
+
+	// Posts every user may see
+	open := "p.visibility IN ('public', 'unlisted')"
+	if listed {
+		open = "p.visibility = 'public'"
+	}
+	if viewer == nil {
+		return open, args
+	}
+
+	// Posts the viewer wrote, and followers-only posts of users they follow
+	args = append(args, viewer.ID, store.FollowAccepted)
+	clause := fmt.Sprintf(`(
+		%[1]s OR p.user_id = $%[2]d OR (
+			p.visibility = 'followers' AND EXISTS (
+				SELECT 1 FROM follows f
+				WHERE f.follower_id = $%[2]d AND f.followee_id = p.user_id AND f.status = $%[3]d
+			)
+		)
+	)`, open, len(args)-1, len(args))
+
+	return clause, args
+}
+
+// scanPost scans a row selected with postColumns
+func scanPost(row scanner) (*store.Post, error) {
+	// Post and user to store the result
+	var post store.Post
+	var user store.User
+	var reactionKinds []string
+	var reactionCounts []int64
+
+	err := row.Scan(
+		&post.ID,
+		&post.Title,
+		&post.Content,
+		&post.UserID,
+		&post.Visibility,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
+		&user.IsPrivate,
+		pq.Array(&reactionKinds),
+		pq.Array(&reactionCounts),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set user and reaction counts
+	post.User = &user
+	post.ReactionCounts = countsByKind(reactionKinds, reactionCounts)
+
+	return &post, nil
+}
+
+// countsByKind pairs up reaction kinds with their counts, selected in the same order
+func countsByKind(kinds []string, counts []int64) map[string]int {
+	byKind := make(map[string]int, len(kinds))
+	for i, kind := range kinds {
+		byKind[kind] = int(counts[i])
+	}
+	return byKind
+}
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"social-api/internal/model"
+	"social-api/internal/store"
+)
+
+// ReactionStore implements store.ReactionStore using PostgreSQL
+type ReactionStore struct {
+	db *sql.DB
+}
+
+// NewReactionStore creates a new PostgreSQL reaction store
+func NewReactionStore(db *sql.DB) *ReactionStore {
+	return &ReactionStore{
+		db: db,
+	}
+}
+
+// Add adds a reaction; the reaction counts are updated by a trigger
+func (s *ReactionStore) Add(ctx context.Context, reaction *store.Reaction) error {
+	// SQL query to insert a reaction unless it already exists
+	query := `
+		INSERT INTO post_reactions (post_id, user_id, kind)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, user_id, kind) DO NOTHING
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, reaction.PostID, reaction.UserID, reaction.Kind)
+	return err
+}
+
+// Remove removes a reaction; the reaction counts are updated by a trigger
+func (s *ReactionStore) Remove(ctx context.Context, postID, userID int64, kind string) error {
+	// SQL query to delete a reaction
+	query := `DELETE FROM post_reactions WHERE post_id = $1 AND user_id = $2 AND kind = $3`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, postID, userID, kind)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListByPost retrieves a page of a post's reactions with the users who left them
+func (s *ReactionStore) ListByPost(ctx context.Context, postID int64, kind *string, pagination model.Pagination) ([]*store.Reaction, int, error) {
+	// Filter by kind if given
+	condition := "r.post_id = $1"
+	args := []interface{}{postID}
+	if kind != nil {
+		condition += " AND r.kind = $2"
+		args = append(args, *kind)
+	}
+
+	// Count query for total records
+	countQuery := `SELECT COUNT(*) FROM post_reactions r WHERE ` + condition
+
+	// SQL query to select a page of reactions
+	query := `
+		SELECT
+			r.post_id, r.user_id, r.kind, r.created_at,
+			u.id, u.username, u.email, u.is_active, u.created_at,
+			u.display_name, u.bio, u.avatar_url, u.is_private
+		FROM post_reactions r
+		JOIN users u ON r.user_id = u.id
+		WHERE ` + condition + `
+		ORDER BY r.created_at DESC, r.user_id DESC
+		LIMIT $` + fmt.Sprint(len(args)+1) + ` OFFSET $` + fmt.Sprint(len(args)+2)
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for total count
+	var totalCount int
+	err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Query for reactions
+	rows, err := s.db.QueryContext(ctx, query, append(args, pagination.PageSize, pagination.GetOffset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	reactions := []*store.Reaction{}
+	for rows.Next() {
+		var reaction store.Reaction
+		var user store.User
+
+		err := rows.Scan(
+			&reaction.PostID,
+			&reaction.UserID,
+			&reaction.Kind,
+			&reaction.CreatedAt,
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.IsActive,
+			&user.CreatedAt,
+			&user.DisplayName,
+			&user.Bio,
+			&user.AvatarURL,
+			&user.IsPrivate,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Set user
+		reaction.User = &user
+		reactions = append(reactions, &reaction)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return reactions, totalCount, nil
+}
+
+// KindsByUser retrieves the kinds of reaction a user left on each of the given posts
+func (s *ReactionStore) KindsByUser(ctx context.Context, userID int64, postIDs []int64) (map[int64][]string, error) {
+	kinds := make(map[int64][]string)
+	if len(postIDs) == 0 {
+		return kinds, nil
+	}
+
+	// SQL query to get the user's reactions on the posts
+	query := `
+		SELECT post_id, kind FROM post_reactions
+		WHERE user_id = $1 AND post_id = ANY($2)
+		ORDER BY post_id, kind
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	rows, err := s.db.QueryContext(ctx, query, userID, pq.Array(postIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	for rows.Next() {
+		var postID int64
+		var kind string
+
+		err := rows.Scan(&postID, &kind)
+		if err != nil {
+			return nil, err
+		}
+		kinds[postID] = append(kinds[postID], kind)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return kinds, nil
+}
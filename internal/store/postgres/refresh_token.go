@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"social-api/internal/store"
+)
+
+// RefreshTokenStore implements store.RefreshTokenStore using PostgreSQL
+type RefreshTokenStore struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenStore creates a new PostgreSQL refresh token store
+func NewRefreshTokenStore(db *sql.DB) *RefreshTokenStore {
+	return &RefreshTokenStore{
+		db: db,
+	}
+}
+
+// Create creates a new refresh token
+func (s *RefreshTokenStore) Create(ctx context.Context, token *store.RefreshToken) error {
+	// SQL query to insert a new refresh token, generating a family ID if none is given
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, session_id, client_id, scopes, token_hash, expires_at)
+		VALUES ($1, COALESCE(NULLIF($2, '')::uuid, uuid_generate_v4()), NULLIF($3, 0), NULLIF($4, 0), $5, $6, $7)
+		RETURNING id, family_id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.FamilyID,
+		token.SessionID,
+		token.ClientID,
+		pq.Array(token.Scopes),
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(
+		&token.ID,
+		&token.FamilyID,
+		&token.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a refresh token by its hash
+func (s *RefreshTokenStore) GetByHash(ctx context.Context, hash string) (*store.RefreshToken, error) {
+	// SQL query to get a refresh token by hash
+	query := `
+		SELECT id, user_id, family_id, COALESCE(session_id, 0), COALESCE(client_id, 0), scopes, token_hash, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Token to store the result
+	var token store.RefreshToken
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.SessionID,
+		&token.ClientID,
+		pq.Array(&token.Scopes),
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkUsed marks an active refresh token as used
+func (s *RefreshTokenStore) MarkUsed(ctx context.Context, id int64) error {
+	// SQL query to mark a token as used only if it is still active,
+	// so that concurrent refreshes with the same token cannot both succeed
+	query := `
+		UPDATE refresh_tokens
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every token in a refresh token family
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	// SQL query to revoke all tokens in the family
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, familyID)
+	return err
+}
+
+// RevokeSession revokes every refresh token linked to a session
+func (s *RefreshTokenStore) RevokeSession(ctx context.Context, sessionID int64) error {
+	// SQL query to revoke all tokens of the session
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE session_id = $1 AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, sessionID)
+	return err
+}
+
+// RevokeAllForUser revokes every refresh token belonging to a user
+func (s *RefreshTokenStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	// SQL query to revoke all of the user's tokens
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"social-api/internal/store"
+)
+
+// SessionStore implements store.SessionStore using PostgreSQL
+type SessionStore struct {
+	db *sql.DB
+}
+
+// NewSessionStore creates a new PostgreSQL session store
+func NewSessionStore(db *sql.DB) *SessionStore {
+	return &SessionStore{
+		db: db,
+	}
+}
+
+// Create creates a new session
+func (s *SessionStore) Create(ctx context.Context, session *store.Session) error {
+	// SQL query to insert a new session
+	query := `
+		INSERT INTO sessions (user_id, kind, token_hash, user_agent, ip, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		session.UserID,
+		session.Kind,
+		session.TokenHash,
+		session.UserAgent,
+		session.IP,
+		session.ExpiresAt,
+	).Scan(
+		&session.ID,
+		&session.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a session by its token hash
+func (s *SessionStore) GetByHash(ctx context.Context, hash string) (*store.Session, error) {
+	// SQL query to get a session by hash
+	query := `
+		SELECT id, user_id, kind, COALESCE(token_hash, ''), user_agent, ip, last_seen_at, expires_at, revoked_at, created_at
+		FROM sessions
+		WHERE token_hash = $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Session to store the result
+	var session store.Session
+
+	// Execute query
+	err := s.db.QueryRowContext(ctx, query, hash).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Kind,
+		&session.TokenHash,
+		&session.UserAgent,
+		&session.IP,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.CreatedAt,
+	)
+
+	// Check for errors
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// IsRevoked reports whether a session has been revoked
+func (s *SessionStore) IsRevoked(ctx context.Context, id int64) (bool, error) {
+	// SQL query to get the revocation time of a session
+	query := `SELECT revoked_at FROM sessions WHERE id = $1`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	var revokedAt *time.Time
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&revokedAt)
+
+	// Check for errors; a missing session counts as revoked
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return revokedAt != nil, nil
+}
+
+// ListActiveByUser retrieves the unexpired, unrevoked sessions of a user
+func (s *SessionStore) ListActiveByUser(ctx context.Context, userID int64) ([]*store.Session, error) {
+	// SQL query to list a user's active sessions
+	query := `
+		SELECT id, user_id, kind, COALESCE(token_hash, ''), user_agent, ip, last_seen_at, expires_at, revoked_at, created_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY COALESCE(last_seen_at, created_at) DESC
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for sessions
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	sessions := []*store.Session{}
+	for rows.Next() {
+		var session store.Session
+
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.Kind,
+			&session.TokenHash,
+			&session.UserAgent,
+			&session.IP,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+			&session.RevokedAt,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Revoke revokes an active session belonging to a user
+func (s *SessionStore) Revoke(ctx context.Context, id, userID int64) error {
+	// SQL query to revoke a session
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every active session of a user
+func (s *SessionStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	// SQL query to revoke all sessions
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// Extend moves the expiry of an active session
+func (s *SessionStore) Extend(ctx context.Context, id int64, expiresAt time.Time) error {
+	// SQL query to update the expiry
+	query := `
+		UPDATE sessions
+		SET expires_at = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, id, expiresAt)
+	return err
+}
+
+// TouchLastSeen records the last-seen time of many sessions in a single statement
+func (s *SessionStore) TouchLastSeen(ctx context.Context, lastSeen map[int64]time.Time) error {
+	if len(lastSeen) == 0 {
+		return nil
+	}
+
+	// Build parallel arrays of IDs and times
+	ids := make([]int64, 0, len(lastSeen))
+	times := make([]string, 0, len(lastSeen))
+	for id, t := range lastSeen {
+		ids = append(ids, id)
+		times = append(times, t.UTC().Format(time.RFC3339Nano))
+	}
+
+	// SQL query to update every session from the arrays, never moving last_seen_at backwards
+	query := `
+		UPDATE sessions AS s
+		SET last_seen_at = v.seen_at
+		FROM unnest($1::bigint[], $2::timestamptz[]) AS v(id, seen_at)
+		WHERE s.id = v.id AND (s.last_seen_at IS NULL OR s.last_seen_at < v.seen_at)
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(times))
+	return err
+}
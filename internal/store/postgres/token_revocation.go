@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TokenRevocationStore implements store.TokenRevocationStore using PostgreSQL
+type TokenRevocationStore struct {
+	db *sql.DB
+}
+
+// NewTokenRevocationStore creates a new PostgreSQL token revocation store
+func NewTokenRevocationStore(db *sql.DB) *TokenRevocationStore {
+	return &TokenRevocationStore{
+		db: db,
+	}
+}
+
+// Revoke adds an access token to the revocation list
+func (s *TokenRevocationStore) Revoke(ctx context.Context, tokenID string, userID int64, expiresAt time.Time) error {
+	// SQL query to insert a revoked token, ignoring tokens already revoked
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, tokenID, userID, expiresAt)
+	return err
+}
+
+// IsRevoked reports whether an access token is on the revocation list
+func (s *TokenRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	// SQL query to check for a revoked token
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, query, tokenID).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// RevokeAllForUser revokes every access token issued to a user before the given time
+func (s *TokenRevocationStore) RevokeAllForUser(ctx context.Context, userID int64, before time.Time) error {
+	// SQL query to upsert the user's revocation cutoff
+	query := `
+		INSERT INTO user_token_revocations (user_id, revoked_before)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	_, err := s.db.ExecContext(ctx, query, userID, before)
+	return err
+}
+
+// RevokedBefore returns the user's revocation cutoff
+func (s *TokenRevocationStore) RevokedBefore(ctx context.Context, userID int64) (time.Time, error) {
+	// SQL query to get the user's revocation cutoff
+	query := `SELECT revoked_before FROM user_token_revocations WHERE user_id = $1`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	var before time.Time
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&before)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	return before, nil
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 
+	"social-api/internal/db"
+	"social-api/internal/model"
 	"social-api/internal/store"
 )
 
@@ -27,8 +31,8 @@ func NewUserStore(db *sql.DB) *UserStore {
 func (s *UserStore) Create(ctx context.Context, user *store.User) error {
 	// SQL query to insert a new user
 	query := `
-		INSERT INTO users (username, email, password, is_active)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (username, email, password, is_active, display_name, bio, avatar_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at
 	`
 
@@ -44,6 +48,9 @@ func (s *UserStore) Create(ctx context.Context, user *store.User) error {
 		user.Email,
 		user.Password.Hash, // Use the exported Hash field
 		user.IsActive,
+		user.DisplayName,
+		user.Bio,
+		user.AvatarURL,
 	).Scan(
 		&user.ID,
 		&user.CreatedAt,
@@ -67,38 +74,49 @@ func (s *UserStore) Create(ctx context.Context, user *store.User) error {
 	return nil
 }
 
-// GetByID retrieves a user by ID
-func (s *UserStore) GetByID(ctx context.Context, id int64) (*store.User, error) {
-	// SQL query to get a user by ID
-	query := `
-		SELECT id, username, email, password, is_active, created_at
-		FROM users
-		WHERE id = $1
-	`
+// userColumns is the column list selected for a user, including the names
+// of the user's roles and the distinct permissions those roles grant
+const userColumns = `
+	u.id, u.username, u.email, u.password, u.is_active, u.created_at, u.email_verified_at,
+	u.deletion_scheduled_at, u.display_name, u.bio, u.avatar_url, u.is_private,
+	ARRAY(
+		SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = u.id ORDER BY r.name
+	),
+	ARRAY(
+		SELECT DISTINCT p FROM user_roles ur JOIN roles r ON r.id = ur.role_id, unnest(r.permissions) p
+		WHERE ur.user_id = u.id ORDER BY p
+	)
+`
 
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanUser scans a row selected with userColumns
+func scanUser(row scanner) (*store.User, error) {
 	// User to store the result
 	var user store.User
 	var passwordHash []byte
 
-	// Execute query
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&passwordHash,
 		&user.IsActive,
 		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.DeletionScheduledAt,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
+		&user.IsPrivate,
+		pq.Array(&user.Roles),
+		pq.Array(&user.Permissions),
 	)
-
-	// Check for errors
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, store.ErrNotFound
-		}
 		return nil, err
 	}
 
@@ -108,32 +126,17 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*store.User, error)
 	return &user, nil
 }
 
-// GetByEmail retrieves a user by email
-func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
-	// SQL query to get a user by email
-	query := `
-		SELECT id, username, email, password, is_active, created_at
-		FROM users
-		WHERE email = $1
-	`
+// getUser retrieves a single user matching a WHERE condition
+func (s *UserStore) getUser(ctx context.Context, condition string, arg interface{}) (*store.User, error) {
+	// SQL query to get a user
+	query := `SELECT ` + userColumns + ` FROM users u WHERE ` + condition
 
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// User to store the result
-	var user store.User
-	var passwordHash []byte
-
 	// Execute query
-	err := s.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&passwordHash,
-		&user.IsActive,
-		&user.CreatedAt,
-	)
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, arg))
 
 	// Check for errors
 	if err != nil {
@@ -143,60 +146,35 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User,
 		return nil, err
 	}
 
-	// Set password hash
-	user.Password.Hash = passwordHash
+	return user, nil
+}
 
-	return &user, nil
+// GetByID retrieves a user by ID
+func (s *UserStore) GetByID(ctx context.Context, id int64) (*store.User, error) {
+	return s.getUser(ctx, "u.id = $1", id)
+}
+
+// GetByEmail retrieves a user by email
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	return s.getUser(ctx, "u.email = $1", email)
 }
 
 // GetByUsername retrieves a user by username
 func (s *UserStore) GetByUsername(ctx context.Context, username string) (*store.User, error) {
-	// SQL query to get a user by username
-	query := `
-		SELECT id, username, email, password, is_active, created_at
-		FROM users
-		WHERE username = $1
-	`
-
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	// User to store the result
-	var user store.User
-	var passwordHash []byte
-
-	// Execute query
-	err := s.db.QueryRowContext(ctx, query, username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&passwordHash,
-		&user.IsActive,
-		&user.CreatedAt,
-	)
-
-	// Check for errors
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, store.ErrNotFound
-		}
-		return nil, err
-	}
-
-	// Set password hash
-	user.Password.Hash = passwordHash
-
-	return &user, nil
+	return s.getUser(ctx, "u.username = $1", username)
 }
 
-// Update updates a user
+// Update updates a user's profile. The password and active state are left
+// alone, so that a copy of the user loaded earlier cannot revert them.
 func (s *UserStore) Update(ctx context.Context, user *store.User) error {
-	// SQL query to update a user
+	// SQL query to update a user's profile; changing the email address
+	// clears its verification
 	query := `
 		UPDATE users
-		SET username = $1, email = $2, is_active = $3
-		WHERE id = $4
+		SET username = $1, email = $2,
+			email_verified_at = CASE WHEN email = $2 THEN email_verified_at ELSE NULL END,
+			display_name = $3, bio = $4, avatar_url = $5, is_private = $6
+		WHERE id = $7
 	`
 
 	// Create a context with timeout
@@ -209,7 +187,10 @@ func (s *UserStore) Update(ctx context.Context, user *store.User) error {
 		query,
 		user.Username,
 		user.Email,
-		user.IsActive,
+		user.DisplayName,
+		user.Bio,
+		user.AvatarURL,
+		user.IsPrivate,
 		user.ID,
 	)
 	if err != nil {
@@ -238,3 +219,408 @@ func (s *UserStore) Update(ctx context.Context, user *store.User) error {
 
 	return nil
 }
+
+// userSortColumns are the columns users can be sorted by. Callers serving
+// other users must not allow sorting by hidden fields such as email.
+var userSortColumns = map[string]bool{
+	"id":         true,
+	"username":   true,
+	"email":      true,
+	"created_at": true,
+}
+
+// List retrieves a page of users matching a filter
+func (s *UserStore) List(ctx context.Context, pagination model.Pagination, filter model.UserFilter) ([]*store.User, int, error) {
+	// Base query for listing users
+	baseQuery := `SELECT ` + userColumns + ` FROM users u`
+
+	// Count query for total records
+	countQuery := `SELECT COUNT(*) FROM users u`
+
+	// Build where clause
+	whereClause, args := s.buildWhereClause(filter)
+	if whereClause != "" {
+		baseQuery += " WHERE " + whereClause
+		countQuery += " WHERE " + whereClause
+	}
+
+	// Add order by clause, falling back to creation time for unknown columns
+	sortBy := pagination.SortBy
+	if !userSortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+	baseQuery += fmt.Sprintf(" ORDER BY u.%s %s, u.id %s", sortBy, pagination.Sort, pagination.Sort)
+
+	// Add pagination
+	baseQuery += " LIMIT $" + fmt.Sprint(len(args)+1) + " OFFSET $" + fmt.Sprint(len(args)+2)
+	args = append(args, pagination.PageSize, pagination.GetOffset())
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Query for total count
+	var totalCount int
+	err := s.db.QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Query for users
+	rows, err := s.db.QueryContext(ctx, baseQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	users := []*store.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, user)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalCount, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// buildWhereClause builds a WHERE clause based on filters
+func (s *UserStore) buildWhereClause(filter model.UserFilter) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	var paramCount int
+
+	// Add search filter (using ILIKE for case-insensitive search)
+	if filter.Search != nil && *filter.Search != "" {
+		paramCount++
+		clauses = append(clauses, fmt.Sprintf("(u.username ILIKE $%d OR u.email ILIKE $%d)", paramCount, paramCount))
+		args = append(args, "%"+*filter.Search+"%")
+	}
+
+	// Add username prefix filter, escaping LIKE wildcards in the prefix
+	if filter.UsernamePrefix != nil && *filter.UsernamePrefix != "" {
+		paramCount++
+		clauses = append(clauses, fmt.Sprintf("lower(u.username) LIKE $%d", paramCount))
+		args = append(args, likeEscaper.Replace(strings.ToLower(*filter.UsernamePrefix))+"%")
+	}
+
+	// Add active filter
+	if filter.IsActive != nil {
+		paramCount++
+		clauses = append(clauses, fmt.Sprintf("u.is_active = $%d", paramCount))
+		args = append(args, *filter.IsActive)
+	}
+
+	// Add role filter
+	if filter.Role != nil && *filter.Role != "" {
+		paramCount++
+		clauses = append(clauses, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = u.id AND r.name = $%d)",
+			paramCount,
+		))
+		args = append(args, *filter.Role)
+	}
+
+	// Join all clauses with AND
+	whereClause := strings.Join(clauses, " AND ")
+
+	return whereClause, args
+}
+
+// SetActive activates or deactivates a user
+func (s *UserStore) SetActive(ctx context.Context, id int64, active bool) error {
+	// SQL query to update the active flag
+	query := `UPDATE users SET is_active = $1 WHERE id = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetPassword replaces a user's password hash unconditionally
+func (s *UserStore) SetPassword(ctx context.Context, id int64, hash []byte) error {
+	// SQL query to replace only the password
+	query := `UPDATE users SET password = $1 WHERE id = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, hash, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces a user's password hash if it is still oldHash
+func (s *UserStore) UpdatePasswordHash(ctx context.Context, id int64, oldHash, newHash []byte) error {
+	// SQL query to swap the hash only if the password has not changed meanwhile
+	query := `
+		UPDATE users
+		SET password = $1
+		WHERE id = $2 AND password = $3
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, newHash, id, oldHash)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// ScheduleDeletion schedules a user's account to be deleted at the given time
+func (s *UserStore) ScheduleDeletion(ctx context.Context, id int64, at time.Time) error {
+	// SQL query to set the deletion time
+	query := `UPDATE users SET deletion_scheduled_at = $1 WHERE id = $2`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// CancelDeletion cancels a user's scheduled account deletion
+func (s *UserStore) CancelDeletion(ctx context.Context, id int64) error {
+	// SQL query to clear the deletion time
+	query := `
+		UPDATE users
+		SET deletion_scheduled_at = NULL
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDueForDeletion retrieves the IDs of users whose deletion time has passed
+func (s *UserStore) ListDueForDeletion(ctx context.Context, limit int) ([]int64, error) {
+	// SQL query to find users due for deletion, oldest first
+	query := `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= NOW()
+		ORDER BY deletion_scheduled_at
+		LIMIT $1
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Process rows
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	// Check for errors in row iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// DeleteScheduled permanently deletes a user whose deletion time has passed,
+// optionally moving their posts and comments to the placeholder user first
+func (s *UserStore) DeleteScheduled(ctx context.Context, id int64, anonymizePosts bool) ([]int64, error) {
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var postIDs []int64
+	err := db.ExecuteTx(ctx, s.db, func(tx *db.Transaction) error {
+		// Lock the user, making sure the deletion was not cancelled meanwhile
+		var userID int64
+		err := tx.QueryRowContext(ctx, `
+			SELECT id FROM users
+			WHERE id = $1 AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= NOW()
+			FOR UPDATE
+		`, id).Scan(&userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrNotFound
+			}
+			return err
+		}
+
+		// Collect the posts, so that callers can evict them from caches
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM posts WHERE user_id = $1`, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		postIDs = nil
+		for rows.Next() {
+			var postID int64
+			if err := rows.Scan(&postID); err != nil {
+				return err
+			}
+			postIDs = append(postIDs, postID)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Keep the posts and comments under the placeholder user
+		if anonymizePosts {
+			_, err = tx.ExecContext(ctx, `UPDATE posts SET user_id = $1 WHERE user_id = $2`, store.DeletedUserID, id)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `UPDATE comments SET user_id = $1 WHERE user_id = $2`, store.DeletedUserID, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Delete the user; everything else they own is removed by ON DELETE CASCADE
+		_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return postIDs, nil
+}
+
+// MarkEmailVerified marks a user's email as verified
+func (s *UserStore) MarkEmailVerified(ctx context.Context, id int64, email string) error {
+	// SQL query to set email_verified_at, guarding against the email having
+	// changed since the verification link was sent
+	query := `
+		UPDATE users
+		SET email_verified_at = NOW()
+		WHERE id = $1 AND email = $2 AND email_verified_at IS NULL
+	`
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Execute query
+	result, err := s.db.ExecContext(ctx, query, id, email)
+	if err != nil {
+		return err
+	}
+
+	// Check if any rows were affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"social-api/internal/model"
+)
+
+// Reaction represents a user's reaction to a post
+type Reaction struct {
+	PostID    int64     `json:"post_id"`
+	UserID    int64     `json:"user_id"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// User is the user who reacted, loaded with the reaction
+	User *User `json:"user,omitempty"`
+}
+
+// ReactionStore defines the interface for post reaction operations
+type ReactionStore interface {
+	// Add adds a reaction, doing nothing if the user already reacted with that kind
+	Add(ctx context.Context, reaction *Reaction) error
+
+	// Remove removes a user's reaction of a kind from a post
+	Remove(ctx context.Context, postID, userID int64, kind string) error
+
+	// ListByPost retrieves a page of a post's reactions, newest first,
+	// optionally only of one kind
+	ListByPost(ctx context.Context, postID int64, kind *string, pagination model.Pagination) ([]*Reaction, int, error)
+
+	// KindsByUser retrieves the kinds of reaction a user left on each of the given posts
+	KindsByUser(ctx context.Context, userID int64, postIDs []int64) (map[int64][]string, error)
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshToken represents a server-side refresh token record
+type RefreshToken struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	FamilyID  string `json:"family_id"`
+	SessionID int64  `json:"session_id,omitempty"`
+
+	// ClientID is the OAuth2 client the token was issued to, and Scopes what
+	// it grants. Both are empty for first-party tokens.
+	ClientID  int64      `json:"client_id,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsExpired reports whether the refresh token has expired
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// RefreshTokenStore defines the interface for refresh token operations
+type RefreshTokenStore interface {
+	// Create creates a new refresh token, starting a new family if FamilyID is empty
+	Create(ctx context.Context, token *RefreshToken) error
+
+	// GetByHash retrieves a refresh token by the hash of its value
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// MarkUsed marks an active refresh token as used, returning ErrNotFound
+	// if it has already been used or revoked
+	MarkUsed(ctx context.Context, id int64) error
+
+	// RevokeFamily revokes every token in a refresh token family
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeSession revokes every refresh token linked to a session
+	RevokeSession(ctx context.Context, sessionID int64) error
+
+	// RevokeAllForUser revokes every refresh token belonging to a user
+	RevokeAllForUser(ctx context.Context, userID int64) error
+}
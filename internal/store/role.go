@@ -0,0 +1,37 @@
+package store
+
+// Built-in role names
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+)
+
+// Permissions granted through roles
+const (
+	PermPostsReadAny      = "posts:read:any"
+	PermPostsUpdateAny    = "posts:update:any"
+	PermPostsDeleteAny    = "posts:delete:any"
+	PermCommentsUpdateAny = "comments:update:any"
+	PermCommentsDeleteAny = "comments:delete:any"
+	PermUsersManage       = "users:manage"
+)
+
+// HasRole reports whether the user has been assigned a role
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether any of the user's roles grants a permission
+func (u *User) HasPermission(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Session kinds
+const (
+	// SessionKindCookie is a browser session carried in a cookie
+	SessionKindCookie = "cookie"
+
+	// SessionKindToken is a token login; its access tokens carry the session ID
+	// and its refresh tokens are linked to it
+	SessionKindToken = "token"
+)
+
+// Session represents a login on one device, either a browser session or a token login
+type Session struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Kind   string `json:"kind"`
+
+	// TokenHash is the hash of the session cookie; empty for token logins
+	TokenHash  string     `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the session is neither expired nor revoked
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// SessionStore defines the interface for session operations
+type SessionStore interface {
+	// Create creates a new session
+	Create(ctx context.Context, session *Session) error
+
+	// GetByHash retrieves a session by the hash of its token
+	GetByHash(ctx context.Context, hash string) (*Session, error)
+
+	// IsRevoked reports whether a session has been revoked. Unknown sessions
+	// are reported as revoked.
+	IsRevoked(ctx context.Context, id int64) (bool, error)
+
+	// ListActiveByUser retrieves the unexpired, unrevoked sessions of a user
+	ListActiveByUser(ctx context.Context, userID int64) ([]*Session, error)
+
+	// Revoke revokes an active session belonging to a user
+	Revoke(ctx context.Context, id, userID int64) error
+
+	// RevokeAllForUser revokes every active session of a user
+	RevokeAllForUser(ctx context.Context, userID int64) error
+
+	// Extend moves the expiry of an active session, used when a token login is refreshed
+	Extend(ctx context.Context, id int64, expiresAt time.Time) error
+
+	// TouchLastSeen records the last-seen time of many sessions at once
+	TouchLastSeen(ctx context.Context, lastSeen map[int64]time.Time) error
+}
@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// TokenRevocationStore defines the interface for access token revocation operations
+type TokenRevocationStore interface {
+	// Revoke adds an access token to the revocation list
+	Revoke(ctx context.Context, tokenID string, userID int64, expiresAt time.Time) error
+
+	// IsRevoked reports whether an access token is on the revocation list
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+
+	// RevokeAllForUser revokes every access token issued to a user before the given time
+	RevokeAllForUser(ctx context.Context, userID int64, before time.Time) error
+
+	// RevokedBefore returns the user's revocation cutoff, or the zero time if there is none
+	RevokedBefore(ctx context.Context, userID int64) (time.Time, error)
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
+	"social-api/internal/model"
 )
 
 // Common errors for user operations
@@ -15,6 +15,10 @@ var (
 	ErrDuplicateUsername = errors.New("username already in use")
 )
 
+// DeletedUserID is the placeholder user that anonymized posts of deleted
+// accounts are attributed to
+const DeletedUserID int64 = 0
+
 // User represents a user in the system
 type User struct {
 	ID        int64     `json:"id"`
@@ -23,39 +27,30 @@ type User struct {
 	Password  Password  `json:"-"`
 	IsActive  bool      `json:"is_active"`
 	CreatedAt time.Time `json:"created_at"`
-}
 
-// Password is a wrapper for user passwords
-type Password struct {
-	Plaintext *string `json:"-"` // Stores the plaintext password temporarily
-	Hash      []byte  `json:"-"` // Stores the hashed password
-}
+	// Public profile fields, empty when not set
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url"`
+
+	// IsPrivate requires the user to approve their followers
+	IsPrivate bool `json:"is_private"`
 
-// Set sets a password by hashing the plaintext
-func (p *Password) Set(plaintext string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintext), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
+	// EmailVerifiedAt is when the user verified their email address, nil if unverified
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 
-	p.Plaintext = &plaintext
-	p.Hash = hash
-	return nil
+	// DeletionScheduledAt is when the user's requested account deletion takes
+	// effect, nil if none is scheduled
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+
+	// Roles and the permissions they grant, loaded with the user
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
 }
 
-// Matches checks if a plaintext password matches the hash
-func (p *Password) Matches(plaintext string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(plaintext))
-	if err != nil {
-		switch {
-		case err == bcrypt.ErrMismatchedHashAndPassword:
-			return false, nil
-		default:
-			return false, err
-		}
-	}
-
-	return true, nil
+// IsEmailVerified reports whether the user has verified their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 // UserStore defines the interface for user operations
@@ -72,6 +67,41 @@ type UserStore interface {
 	// GetByUsername retrieves a user by username
 	GetByUsername(ctx context.Context, username string) (*User, error)
 
-	// Update updates a user
+	// Update updates a user's username, email address and profile fields.
+	// The password and active state are changed with SetPassword,
+	// UpdatePasswordHash and SetActive.
 	Update(ctx context.Context, user *User) error
+
+	// List retrieves a page of users matching a filter, with the total count
+	List(ctx context.Context, pagination model.Pagination, filter model.UserFilter) ([]*User, int, error)
+
+	// SetActive activates or deactivates a user
+	SetActive(ctx context.Context, id int64, active bool) error
+
+	// SetPassword replaces a user's password hash unconditionally
+	SetPassword(ctx context.Context, id int64, hash []byte) error
+
+	// UpdatePasswordHash replaces a user's password hash, provided it is still
+	// oldHash, so that a concurrent password change is never overwritten
+	UpdatePasswordHash(ctx context.Context, id int64, oldHash, newHash []byte) error
+
+	// ScheduleDeletion schedules a user's account to be deleted at the given time
+	ScheduleDeletion(ctx context.Context, id int64, at time.Time) error
+
+	// CancelDeletion cancels a user's scheduled account deletion
+	CancelDeletion(ctx context.Context, id int64) error
+
+	// ListDueForDeletion retrieves the IDs of up to limit users whose scheduled
+	// deletion time has passed
+	ListDueForDeletion(ctx context.Context, limit int) ([]int64, error)
+
+	// DeleteScheduled permanently deletes a user whose scheduled deletion time
+	// has passed. Their posts and comments are deleted with them, or moved to
+	// DeletedUserID if anonymizePosts is set. It returns the IDs of the posts
+	// that were deleted or moved.
+	DeleteScheduled(ctx context.Context, id int64, anonymizePosts bool) ([]int64, error)
+
+	// MarkEmailVerified marks a user's email as verified, provided it is still
+	// the given address and has not been verified already
+	MarkEmailVerified(ctx context.Context, id int64, email string) error
 }
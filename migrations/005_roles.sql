@@ -0,0 +1,23 @@
+-- Role-based access control
+
+-- Roles table; each role grants a set of permissions
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- User role assignments
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+-- Seed built-in roles
+INSERT INTO roles (name, permissions) VALUES
+    ('admin', ARRAY['posts:update:any', 'posts:delete:any']),
+    ('moderator', ARRAY['posts:update:any', 'posts:delete:any'])
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,8 @@
+-- Email verification
+
+-- Track when a user proved ownership of their email address
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Existing accounts were created before verification was required; treat
+-- their addresses as verified rather than locking them out
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
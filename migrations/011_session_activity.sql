@@ -0,0 +1,12 @@
+-- Sessions for token logins and last-seen tracking
+
+-- Token logins have a session record but no session cookie
+ALTER TABLE sessions ALTER COLUMN token_hash DROP NOT NULL;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'cookie';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE;
+
+-- Refresh tokens belong to the session of the login that issued them
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,15 @@
+-- External identity provider accounts
+
+-- Identities linked to local users, keyed by provider and the provider's subject ID
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(provider, subject)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
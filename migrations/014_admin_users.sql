@@ -0,0 +1,9 @@
+-- Admin user management
+
+-- Grant user management to the admin role
+UPDATE roles
+SET permissions = array_append(permissions, 'users:manage')
+WHERE name = 'admin' AND NOT ('users:manage' = ANY(permissions));
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
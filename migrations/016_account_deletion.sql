@@ -0,0 +1,13 @@
+-- Account deletion
+
+-- When a user's requested deletion takes effect, NULL if none is scheduled
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP WITH TIME ZONE;
+
+-- Placeholder author that anonymized posts are moved to when their author is
+-- deleted. It is inactive and its password is a discarded random string.
+INSERT INTO users (id, username, email, password, is_active)
+VALUES (0, '[deleted]', 'deleted@users.invalid', '$2a$10$rzt1xUamSFYqh8l8HOQ/Le5cNEb3TxitJdvobsaWWBeNVjOlqoQOO', false)
+ON CONFLICT (id) DO NOTHING;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Comments on posts
+
+-- Comments table; replies point to the comment they answer
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments(user_id);
+
+-- Create a trigger to automatically update the updated_at column for comments
+CREATE TRIGGER update_comments_updated_at
+BEFORE UPDATE ON comments
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Let moderators manage comments like posts
+UPDATE roles
+SET permissions = permissions || ARRAY['comments:update:any', 'comments:delete:any']
+WHERE name IN ('admin', 'moderator') AND NOT ('comments:delete:any' = ANY(permissions));
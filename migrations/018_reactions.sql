@@ -0,0 +1,51 @@
+-- Reactions on posts
+
+-- Reactions table; a user can leave one reaction of each kind on a post
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id, kind)
+);
+
+-- Reaction counts per post and kind, kept up to date by a trigger so that
+-- listing posts does not have to count reactions
+CREATE TABLE IF NOT EXISTS post_reaction_counts (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (post_id, kind)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_id ON post_reactions(post_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_post_reactions_user_id ON post_reactions(user_id);
+
+-- Create a function to count reactions as they are added and removed,
+-- including removals cascaded from deleted users
+CREATE OR REPLACE FUNCTION update_post_reaction_counts()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO post_reaction_counts (post_id, kind, count)
+        VALUES (NEW.post_id, NEW.kind, 1)
+        ON CONFLICT (post_id, kind) DO UPDATE SET count = post_reaction_counts.count + 1;
+        RETURN NEW;
+    END IF;
+
+    UPDATE post_reaction_counts
+    SET count = count - 1
+    WHERE post_id = OLD.post_id AND kind = OLD.kind;
+
+    DELETE FROM post_reaction_counts
+    WHERE post_id = OLD.post_id AND kind = OLD.kind AND count <= 0;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create a trigger to maintain the reaction counts
+CREATE TRIGGER update_post_reaction_counts
+AFTER INSERT OR DELETE ON post_reactions
+FOR EACH ROW
+EXECUTE FUNCTION update_post_reaction_counts();
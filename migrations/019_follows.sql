@@ -0,0 +1,18 @@
+-- Follow graph between users
+
+-- Private accounts approve their followers
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Follows table; a follow of a private account is pending until approved
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'accepted')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_follows_follower_id ON follows(follower_id, status, created_at);
@@ -0,0 +1,4 @@
+-- Home timelines
+
+-- Create indexes for reading the newest posts of followed users
+CREATE INDEX IF NOT EXISTS idx_posts_user_id_id ON posts(user_id, id DESC);
@@ -0,0 +1,12 @@
+-- Post visibility levels
+
+-- Who can see a post: everyone (public), everyone with a link but only listed
+-- to its author (unlisted), the author's followers (followers) or only the
+-- author (private)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'unlisted', 'followers', 'private'));
+
+-- Let moderators see every post they may change or remove
+UPDATE roles
+SET permissions = permissions || ARRAY['posts:read:any']
+WHERE name IN ('admin', 'moderator') AND NOT ('posts:read:any' = ANY(permissions));